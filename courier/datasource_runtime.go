@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"strings"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework-timeouts/datasource/timeouts"
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
@@ -19,7 +18,10 @@ import (
 	"github.com/seal-io/terraform-provider-courier/pkg/runtime"
 )
 
-var _ datasource.DataSource = (*DataSourceRuntime)(nil)
+var (
+	_ datasource.DataSource              = (*DataSourceRuntime)(nil)
+	_ datasource.DataSourceWithConfigure = (*DataSourceRuntime)(nil)
+)
 
 type (
 	DataSourceRuntime struct {
@@ -30,6 +32,9 @@ type (
 		Timeouts timeouts.Value          `tfsdk:"timeouts"`
 
 		Classes map[string]types.List `tfsdk:"classes"`
+
+		// config is the provider configuration.
+		config ProviderConfig
 	}
 
 	DataSourceRuntimeAuthn struct {
@@ -69,7 +74,7 @@ func (r *DataSourceRuntime) Schema(
 			"source": schema.StringAttribute{
 				Optional: true,
 				Description: `The source to fetch the runtime, 
-only support a git repository at present.
+only support a git repository at present, defaults to the runtime source of the provider.
 
   - For example:
     - https://github.com/foo/bar, clone the HEAD commit of the default branch.
@@ -131,18 +136,32 @@ only support a git repository at present.
 	}
 }
 
+// Reflect returns the runtime source,
+// the source defaults to the runtime of the provider if not specified.
 func (r *DataSourceRuntime) Reflect(
 	ctx context.Context,
 ) (runtime.Source, error) {
-	if r.Source.ValueString() == "" {
-		return runtime.BuiltinSource(), nil
+	var (
+		source   = r.Source
+		authn    = r.Authn
+		insecure = r.Insecure
+	)
+
+	if source.ValueString() == "" {
+		if r.config.Runtime == nil {
+			return runtime.BuiltinSource(), nil
+		}
+
+		source = r.config.Runtime.Source
+		authn = r.config.Runtime.Authn
+		insecure = r.config.Runtime.Insecure
 	}
 
 	opts := runtime.ExternalSourceOptions{
-		Source:   r.Source.ValueString(),
-		Insecure: r.Insecure.ValueBool(),
+		Source:   source.ValueString(),
+		Insecure: insecure.ValueBool(),
 	}
-	if au := r.Authn; au != nil {
+	if au := authn; au != nil {
 		opts.Authn = runtime.ExternalSourceOptionAuthn{
 			Type:   au.Type.ValueString(),
 			User:   au.User.ValueString(),
@@ -153,6 +172,16 @@ func (r *DataSourceRuntime) Reflect(
 	return runtime.ExternalSource(ctx, opts)
 }
 
+func (r *DataSourceRuntime) Configure(
+	ctx context.Context,
+	req datasource.ConfigureRequest,
+	resp *datasource.ConfigureResponse,
+) {
+	cfg, diags := getProviderConfig(req.ProviderData)
+	resp.Diagnostics.Append(diags...)
+	r.config = cfg
+}
+
 func (r *DataSourceRuntime) Read(
 	ctx context.Context,
 	req datasource.ReadRequest,
@@ -165,10 +194,12 @@ func (r *DataSourceRuntime) Read(
 		return
 	}
 
+	plan.config = r.config
+
 	var src runtime.Source
 	{
 		// Get Timeout.
-		timeout, diags := plan.Timeouts.Read(ctx, 10*time.Minute)
+		timeout, diags := plan.Timeouts.Read(ctx, r.config.GetTimeout())
 		resp.Diagnostics.Append(diags...)
 		if resp.Diagnostics.HasError() {
 			return
@@ -2,12 +2,13 @@ package courier
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework-timeouts/datasource/timeouts"
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
@@ -18,7 +19,10 @@ import (
 	"github.com/seal-io/terraform-provider-courier/pkg/target"
 )
 
-var _ datasource.DataSource = (*DataSourceTarget)(nil)
+var (
+	_ datasource.DataSource              = (*DataSourceTarget)(nil)
+	_ datasource.DataSourceWithConfigure = (*DataSourceTarget)(nil)
+)
 
 type (
 	DataSourceTarget struct {
@@ -28,32 +32,67 @@ type (
 		OS      types.String `tfsdk:"os"`
 		Arch    types.String `tfsdk:"arch"`
 		Version types.String `tfsdk:"version"`
+
+		// config is the provider configuration.
+		config ProviderConfig
 	}
 
 	DataSourceTargetHost struct {
 		Address  types.String                `tfsdk:"address"`
-		Authn    DataSourceTargetHostAuthn   `tfsdk:"authn"`
+		Authn    *DataSourceTargetHostAuthn  `tfsdk:"authn"`
+		HostKey  *DataSourceTargetHostKey    `tfsdk:"host_key"`
+		CACert   types.String                `tfsdk:"ca_cert"`
 		Insecure types.Bool                  `tfsdk:"insecure"`
 		Proxies  []DataSourceTargetHostProxy `tfsdk:"proxies"`
 	}
 
 	DataSourceTargetHostAuthn struct {
-		Type   types.String `tfsdk:"type"`
-		User   types.String `tfsdk:"user"`
-		Secret types.String `tfsdk:"secret"`
-		Agent  types.Bool   `tfsdk:"agent"`
+		Type        types.String `tfsdk:"type"`
+		User        types.String `tfsdk:"user"`
+		Secret      types.String `tfsdk:"secret"`
+		Agent       types.Bool   `tfsdk:"agent"`
+		Passphrase  types.String `tfsdk:"passphrase"`
+		Certificate types.String `tfsdk:"certificate"`
+		Password    types.String `tfsdk:"password"`
+
+		Become   *DataSourceTargetHostAuthnBecome   `tfsdk:"become"`
+		Kerberos *DataSourceTargetHostAuthnKerberos `tfsdk:"kerberos"`
+	}
+
+	DataSourceTargetHostAuthnBecome struct {
+		Method   types.String `tfsdk:"method"`
+		User     types.String `tfsdk:"user"`
+		Password types.String `tfsdk:"password"`
+	}
+
+	DataSourceTargetHostAuthnKerberos struct {
+		Realm    types.String `tfsdk:"realm"`
+		Krb5Conf types.String `tfsdk:"krb5_conf"`
+		Keytab   types.String `tfsdk:"keytab"`
+		CCache   types.String `tfsdk:"ccache"`
+		SPN      types.String `tfsdk:"spn"`
+	}
+
+	DataSourceTargetHostKey struct {
+		KnownHosts      types.String `tfsdk:"known_hosts"`
+		Fingerprints    types.List   `tfsdk:"fingerprints"`
+		TrustOnFirstUse types.Bool   `tfsdk:"trust_on_first_use"`
 	}
 
 	DataSourceTargetHostProxy struct {
 		Address  types.String                   `tfsdk:"address"`
 		Authn    DataSourceTargetHostProxyAuthn `tfsdk:"authn"`
+		HostKey  *DataSourceTargetHostKey       `tfsdk:"host_key"`
 		Insecure types.Bool                     `tfsdk:"insecure"`
 	}
 
 	DataSourceTargetHostProxyAuthn struct {
-		Type   types.String `tfsdk:"type"`
-		User   types.String `tfsdk:"user"`
-		Secret types.String `tfsdk:"secret"`
+		Type        types.String `tfsdk:"type"`
+		User        types.String `tfsdk:"user"`
+		Secret      types.String `tfsdk:"secret"`
+		Passphrase  types.String `tfsdk:"passphrase"`
+		Certificate types.String `tfsdk:"certificate"`
+		Password    types.String `tfsdk:"password"`
 	}
 )
 
@@ -66,7 +105,7 @@ func (r *DataSourceTarget) State(
 ) diag.Diagnostics {
 	var diags diag.Diagnostics
 
-	h, err := r.Host.Reflect(ctx)
+	h, err := r.Host.Reflect(ctx, r.config)
 	if err != nil {
 		diags.Append(diag.NewAttributeErrorDiagnostic(
 			path.Root("host"),
@@ -95,38 +134,182 @@ func (r *DataSourceTarget) State(
 	return diags
 }
 
-func (r DataSourceTargetHost) Reflect(
-	_ context.Context,
+// Reflect returns the host,
+// the authn and proxies default to the given provider configuration if not specified.
+func (r *DataSourceTargetHost) Reflect(
+	ctx context.Context,
+	cfg ProviderConfig,
 ) (target.Host, error) {
+	au := r.Authn
+	if au == nil {
+		au = cfg.Authn
+	}
+
+	if au == nil {
+		return nil, errors.New("missing authn")
+	}
+
 	opts := target.HostOptions{
 		HostOption: target.HostOption{
 			Address: r.Address.ValueString(),
 			Authn: target.HostOptionAuthn{
-				Type:   r.Authn.Type.ValueString(),
-				User:   r.Authn.User.ValueString(),
-				Secret: r.Authn.Secret.ValueString(),
-				Agent:  r.Authn.Agent.ValueBool(),
+				Type:        au.Type.ValueString(),
+				User:        au.User.ValueString(),
+				Secret:      au.Secret.ValueString(),
+				Agent:       au.Agent.ValueBool(),
+				Passphrase:  au.Passphrase.ValueString(),
+				Certificate: au.Certificate.ValueString(),
+				Password:    au.Password.ValueString(),
+				Become:      au.Become.Reflect(),
+				Kerberos:    au.Kerberos.Reflect(),
 			},
-			Insecure: r.Insecure.ValueBool(),
+			HostKey:  r.HostKey.Reflect(ctx),
+			CACert:   r.CACert.ValueString(),
+			Insecure: r.Insecure.ValueBool() || cfg.Insecure.ValueBool(),
 		},
 	}
 
-	opts.Proxies = make([]target.HostOption, 0, len(r.Proxies))
-	for i := range r.Proxies {
-		p := r.Proxies[i]
+	proxies := r.Proxies
+	if proxies == nil {
+		// Copy the host keys of the provider to avoid recording the trusted fingerprints back.
+		proxies = make([]DataSourceTargetHostProxy, len(cfg.Proxies))
+		for i := range cfg.Proxies {
+			proxies[i] = cfg.Proxies[i]
+			if hk := cfg.Proxies[i].HostKey; hk != nil {
+				hkc := *hk
+				proxies[i].HostKey = &hkc
+			}
+		}
+	}
+
+	opts.Proxies = make([]target.HostOption, 0, len(proxies))
+	for i := range proxies {
+		p := &proxies[i]
 		opts.Proxies = append(opts.Proxies,
 			target.HostOption{
 				Address: p.Address.ValueString(),
 				Authn: target.HostOptionAuthn{
-					Type:   p.Authn.Type.ValueString(),
-					User:   p.Authn.User.ValueString(),
-					Secret: p.Authn.Secret.ValueString(),
+					Type:        p.Authn.Type.ValueString(),
+					User:        p.Authn.User.ValueString(),
+					Secret:      p.Authn.Secret.ValueString(),
+					Passphrase:  p.Authn.Passphrase.ValueString(),
+					Certificate: p.Authn.Certificate.ValueString(),
+					Password:    p.Authn.Password.ValueString(),
 				},
-				Insecure: p.Insecure.ValueBool(),
+				HostKey:  p.HostKey.Reflect(ctx),
+				Insecure: p.Insecure.ValueBool() || cfg.Insecure.ValueBool(),
 			})
 	}
 
-	return target.NewHost(opts)
+	return cfg.pool.Get(opts)
+}
+
+// Reflect returns the escalation options,
+// the method defaults to sudo and the user defaults to root if specified.
+func (r *DataSourceTargetHostAuthnBecome) Reflect() target.HostOptionBecome {
+	if r == nil {
+		return target.HostOptionBecome{}
+	}
+
+	b := target.HostOptionBecome{
+		Method:   r.Method.ValueString(),
+		User:     r.User.ValueString(),
+		Password: r.Password.ValueString(),
+	}
+
+	if b.Method == "" {
+		b.Method = "sudo"
+	}
+
+	if b.User == "" {
+		b.User = "root"
+	}
+
+	return b
+}
+
+// Reflect returns the Kerberos options.
+func (r *DataSourceTargetHostAuthnKerberos) Reflect() target.HostOptionKerberos {
+	if r == nil {
+		return target.HostOptionKerberos{}
+	}
+
+	return target.HostOptionKerberos{
+		Realm:  r.Realm.ValueString(),
+		Config: r.Krb5Conf.ValueString(),
+		Keytab: r.Keytab.ValueString(),
+		CCache: r.CCache.ValueString(),
+		SPN:    r.SPN.ValueString(),
+	}
+}
+
+// CarryHostKey carries the unknown fingerprints from the given host and its proxies
+// matched by address, returns true if any carried.
+func (r *DataSourceTargetHost) CarryHostKey(l DataSourceTargetHost) bool {
+	carried := r.HostKey.Carry(l.HostKey)
+
+	proxiesIndex := make(map[string]*DataSourceTargetHostKey, len(l.Proxies))
+	for i := range l.Proxies {
+		proxiesIndex[l.Proxies[i].Address.ValueString()] = l.Proxies[i].HostKey
+	}
+
+	for i := range r.Proxies {
+		hk, ok := proxiesIndex[r.Proxies[i].Address.ValueString()]
+		if ok && r.Proxies[i].HostKey.Carry(hk) {
+			carried = true
+		}
+	}
+
+	return carried
+}
+
+// Carry carries the fingerprints from the given host key if unknown,
+// returns true if carried.
+func (r *DataSourceTargetHostKey) Carry(l *DataSourceTargetHostKey) bool {
+	if r == nil || !r.Fingerprints.IsUnknown() ||
+		l == nil || l.Fingerprints.IsNull() || l.Fingerprints.IsUnknown() {
+		return false
+	}
+
+	r.Fingerprints = l.Fingerprints
+
+	return true
+}
+
+// Unpinned returns true if the host key is trusted on first use without fingerprints,
+// which accepts any host key unless the trusted fingerprint is recorded.
+func (r *DataSourceTargetHostKey) Unpinned() bool {
+	return r != nil && r.TrustOnFirstUse.ValueBool() &&
+		!r.Fingerprints.IsUnknown() && len(r.Fingerprints.Elements()) == 0
+}
+
+// Reflect returns the host key option,
+// the fingerprint trusted on first use is recorded back to Fingerprints.
+func (r *DataSourceTargetHostKey) Reflect(
+	ctx context.Context,
+) target.HostOptionHostKey {
+	if r == nil {
+		return target.HostOptionHostKey{}
+	}
+
+	opt := target.HostOptionHostKey{
+		KnownHosts:      r.KnownHosts.ValueString(),
+		TrustOnFirstUse: r.TrustOnFirstUse.ValueBool(),
+	}
+
+	if r.Fingerprints.IsUnknown() {
+		r.Fingerprints = types.ListNull(types.StringType)
+	} else {
+		_ = r.Fingerprints.ElementsAs(ctx, &opt.Fingerprints, false)
+	}
+
+	opt.Trusted = func(fingerprint string) {
+		r.Fingerprints = types.ListValueMust(types.StringType, []attr.Value{
+			types.StringValue(fingerprint),
+		})
+	}
+
+	return opt
 }
 
 func (r *DataSourceTarget) Metadata(
@@ -161,8 +344,9 @@ in the form of [schema://](ip|dns)[:port].`,
 						},
 					},
 					"authn": schema.SingleNestedAttribute{
-						Required:    true,
-						Description: `The authentication for accessing the host.`,
+						Optional: true,
+						Description: `The authentication for accessing the host, 
+defaults to the authn of the provider.`,
 						Attributes: map[string]schema.Attribute{
 							"type": schema.StringAttribute{
 								Required:    true,
@@ -176,27 +360,48 @@ in the form of [schema://](ip|dns)[:port].`,
 								Description: `The user to authenticate when accessing the target.`,
 							},
 							"secret": schema.StringAttribute{
-								Optional: true,
-								Description: `The secret to authenticate when accessing the target, 
-either password or private key.`,
-								Sensitive: true,
+								Optional:    true,
+								Description: hostAuthnSecretDescription,
+								Sensitive:   true,
 							},
 							"agent": schema.BoolAttribute{
-								Optional: true,
-								Computed: true,
-								Description: `Specify to access the target with agent,
-either SSH agent if type is "ssh" or NTLM if type is "winrm".`,
+								Optional:    true,
+								Computed:    true,
+								Description: hostAuthnAgentDescription,
+							},
+							"passphrase": schema.StringAttribute{
+								Optional:    true,
+								Description: hostAuthnPassphraseDescription,
+								Sensitive:   true,
+							},
+							"certificate": schema.StringAttribute{
+								Optional:    true,
+								Description: hostAuthnCertificateDescription,
+							},
+							"password": schema.StringAttribute{
+								Optional:    true,
+								Description: hostAuthnPasswordDescription,
+								Sensitive:   true,
 							},
+							"become":   targetBecomeSchema(),
+							"kerberos": targetKerberosSchema(),
 						},
 					},
+					"host_key": targetHostKeySchema("target"),
+					"ca_cert": schema.StringAttribute{
+						Optional: true,
+						Description: `The PEM encoded CA certificates to verify the target, 
+only works if type is "winrm" and the address is in the form of https://.`,
+					},
 					"insecure": schema.BoolAttribute{
-						Optional:    true,
-						Description: `Specify to access the target with insecure mode.`,
+						Optional: true,
+						Description: `Specify to access the target with insecure mode,
+which skips the host key or the certificate verification.`,
 					},
 					"proxies": schema.ListNestedAttribute{
 						Optional: true,
 						Description: `The proxies before accessing the target, 
-either a bastion host or a jump host.`,
+either a bastion host or a jump host, defaults to the proxies of the provider.`,
 						NestedObject: schema.NestedAttributeObject{
 							Attributes: map[string]schema.Attribute{
 								"address": schema.StringAttribute{
@@ -223,19 +428,34 @@ in the form of [schema://](ip|dns)[:port].`,
 										},
 										"user": schema.StringAttribute{
 											Optional:    true,
-											Description: `The user to authenticate when accessing the proxy.`,
+											Description: proxyAuthnUserDescription,
 										},
 										"secret": schema.StringAttribute{
-											Optional: true,
-											Description: `The secret to authenticate when accessing the proxy, 
-either password or private key.`,
-											Sensitive: true,
+											Optional:    true,
+											Description: proxyAuthnSecretDescription,
+											Sensitive:   true,
+										},
+										"passphrase": schema.StringAttribute{
+											Optional:    true,
+											Description: hostAuthnPassphraseDescription,
+											Sensitive:   true,
+										},
+										"certificate": schema.StringAttribute{
+											Optional:    true,
+											Description: proxyAuthnCertificateDescription,
+										},
+										"password": schema.StringAttribute{
+											Optional:    true,
+											Description: proxyAuthnPasswordDescription,
+											Sensitive:   true,
 										},
 									},
 								},
+								"host_key": targetHostKeySchema("proxy"),
 								"insecure": schema.BoolAttribute{
-									Optional:    true,
-									Description: `Specify to access the target with insecure mode.`,
+									Optional: true,
+									Description: `Specify to access the proxy with insecure mode,
+which skips the host key verification.`,
 								},
 							},
 						},
@@ -259,6 +479,99 @@ either password or private key.`,
 	}
 }
 
+// targetBecomeSchema returns the schema of the privilege escalation.
+func targetBecomeSchema() schema.SingleNestedAttribute {
+	return schema.SingleNestedAttribute{
+		Optional:    true,
+		Description: hostBecomeDescription,
+		Attributes: map[string]schema.Attribute{
+			"method": schema.StringAttribute{
+				Optional:    true,
+				Description: hostBecomeMethodDescription,
+				Validators:  hostBecomeMethodValidators(),
+			},
+			"user": schema.StringAttribute{
+				Optional:    true,
+				Description: hostBecomeUserDescription,
+				Validators:  hostBecomeUserValidators(),
+			},
+			"password": schema.StringAttribute{
+				Optional:    true,
+				Description: hostBecomePasswordDescription,
+				Sensitive:   true,
+			},
+		},
+	}
+}
+
+// targetKerberosSchema returns the schema of the Kerberos authentication.
+func targetKerberosSchema() schema.SingleNestedAttribute {
+	return schema.SingleNestedAttribute{
+		Optional:    true,
+		Description: hostKerberosDescription,
+		Attributes: map[string]schema.Attribute{
+			"realm": schema.StringAttribute{
+				Required:    true,
+				Description: hostKerberosRealmDescription,
+				Validators:  hostKerberosRealmValidators(),
+			},
+			"krb5_conf": schema.StringAttribute{
+				Optional:    true,
+				Description: hostKerberosKrb5ConfDescription,
+			},
+			"keytab": schema.StringAttribute{
+				Optional:    true,
+				Description: hostKerberosKeytabDescription,
+				Validators:  hostKerberosKeytabValidators(),
+			},
+			"ccache": schema.StringAttribute{
+				Optional:    true,
+				Description: hostKerberosCCacheDescription,
+			},
+			"spn": schema.StringAttribute{
+				Optional:    true,
+				Description: hostKerberosSPNDescription,
+			},
+		},
+	}
+}
+
+// targetHostKeySchema returns the schema of the host key verification of the given subject.
+func targetHostKeySchema(subject string) schema.SingleNestedAttribute {
+	return schema.SingleNestedAttribute{
+		Optional:    true,
+		Description: hostKeyDescription(subject),
+		Attributes: map[string]schema.Attribute{
+			"known_hosts": schema.StringAttribute{
+				Optional:    true,
+				Description: hostKeyKnownHostsDescription,
+			},
+			"fingerprints": schema.ListAttribute{
+				Optional:    true,
+				Computed:    true,
+				ElementType: types.StringType,
+				Description: hostKeyFingerprintsDescription,
+			},
+			"trust_on_first_use": schema.BoolAttribute{
+				Optional: true,
+				Description: `Specify to trust the unknown host key on first use, 
+which is rejected without fingerprints, 
+as the data source cannot record the trusted fingerprint to pin the host key.`,
+			},
+		},
+	}
+}
+
+func (r *DataSourceTarget) Configure(
+	ctx context.Context,
+	req datasource.ConfigureRequest,
+	resp *datasource.ConfigureResponse,
+) {
+	cfg, diags := getProviderConfig(req.ProviderData)
+	resp.Diagnostics.Append(diags...)
+	r.config = cfg
+}
+
 func (r *DataSourceTarget) Read(
 	ctx context.Context,
 	req datasource.ReadRequest,
@@ -271,9 +584,38 @@ func (r *DataSourceTarget) Read(
 		return
 	}
 
+	plan.config = r.config
+
+	// Reject trusting on first use,
+	// as the data source is read again on every run without the recorded fingerprints.
+	if plan.Host.HostKey.Unpinned() {
+		resp.Diagnostics.Append(diag.NewAttributeErrorDiagnostic(
+			path.Root("host").AtName("host_key").AtName("trust_on_first_use"),
+			"Unsupported Trust On First Use",
+			"Cannot record the trusted fingerprint in the data source, "+
+				"specify the fingerprints or the known_hosts to pin the host key",
+		))
+	}
+
+	for i := range plan.Host.Proxies {
+		if plan.Host.Proxies[i].HostKey.Unpinned() {
+			resp.Diagnostics.Append(diag.NewAttributeErrorDiagnostic(
+				path.Root("host").AtName("proxies").AtListIndex(i).
+					AtName("host_key").AtName("trust_on_first_use"),
+				"Unsupported Trust On First Use",
+				"Cannot record the trusted fingerprint in the data source, "+
+					"specify the fingerprints or the known_hosts to pin the host key",
+			))
+		}
+	}
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	{
 		// Get Timeout.
-		timeout, diags := plan.Timeouts.Read(ctx, 10*time.Minute)
+		timeout, diags := plan.Timeouts.Read(ctx, r.config.GetTimeout())
 		resp.Diagnostics.Append(diags...)
 		if resp.Diagnostics.HasError() {
 			return
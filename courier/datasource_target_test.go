@@ -6,8 +6,11 @@ import (
 	"os/exec"
 	"testing"
 
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-testing/config"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestAccDataSourceTarget_basic(t *testing.T) {
@@ -171,3 +174,58 @@ data "courier_target" "test" {
 		},
 	})
 }
+
+func TestDataSourceTargetHostKey_Unpinned(t *testing.T) {
+	pinned := types.ListValueMust(types.StringType, []attr.Value{
+		types.StringValue("SHA256:x"),
+	})
+
+	testCases := []struct {
+		name     string
+		hk       *DataSourceTargetHostKey
+		expected bool
+	}{
+		{
+			name:     "nil",
+			hk:       nil,
+			expected: false,
+		},
+		{
+			name: "not trusted on first use",
+			hk: &DataSourceTargetHostKey{
+				Fingerprints: types.ListNull(types.StringType),
+			},
+			expected: false,
+		},
+		{
+			name: "trusted on first use without fingerprints",
+			hk: &DataSourceTargetHostKey{
+				Fingerprints:    types.ListNull(types.StringType),
+				TrustOnFirstUse: types.BoolValue(true),
+			},
+			expected: true,
+		},
+		{
+			name: "trusted on first use with fingerprints",
+			hk: &DataSourceTargetHostKey{
+				Fingerprints:    pinned,
+				TrustOnFirstUse: types.BoolValue(true),
+			},
+			expected: false,
+		},
+		{
+			name: "trusted on first use with fingerprints to record",
+			hk: &DataSourceTargetHostKey{
+				Fingerprints:    types.ListUnknown(types.StringType),
+				TrustOnFirstUse: types.BoolValue(true),
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.hk.Unpinned())
+		})
+	}
+}
@@ -2,11 +2,21 @@ package courier
 
 import (
 	"context"
+	"fmt"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 
+	"github.com/seal-io/terraform-provider-courier/pkg/target"
 	"github.com/seal-io/terraform-provider-courier/utils/version"
 )
 
@@ -20,13 +30,80 @@ const (
 )
 
 type (
-	Provider struct{}
+	Provider struct {
+		pool *target.Pool
+	}
+
+	ProviderConfig struct {
+		InstallDir  types.String                `tfsdk:"install_dir"`
+		Authn       *DataSourceTargetHostAuthn  `tfsdk:"authn"`
+		Proxies     []DataSourceTargetHostProxy `tfsdk:"proxies"`
+		Insecure    types.Bool                  `tfsdk:"insecure"`
+		Runtime     *ProviderConfigRuntime      `tfsdk:"runtime"`
+		Timeout     types.String                `tfsdk:"timeout"`
+		Parallelism types.Int64                 `tfsdk:"parallelism"`
+
+		// pool shares the hosts across the data sources and resources.
+		pool *target.Pool
+	}
+
+	ProviderConfigRuntime struct {
+		Source   types.String            `tfsdk:"source"`
+		Authn    *DataSourceRuntimeAuthn `tfsdk:"authn"`
+		Insecure types.Bool              `tfsdk:"insecure"`
+	}
 )
 
+// GetTimeout returns the default timeout of the operations,
+// or 10 minutes if not set.
+func (c ProviderConfig) GetTimeout() time.Duration {
+	if d, err := time.ParseDuration(c.Timeout.ValueString()); err == nil && d > 0 {
+		return d
+	}
+
+	return 10 * time.Minute
+}
+
+// GetParallelism returns the maximum number of targets to operate at once,
+// or 0 if no limit.
+func (c ProviderConfig) GetParallelism() int {
+	return int(c.Parallelism.ValueInt64())
+}
+
+// getProviderConfig returns the provider configuration from the given provider data,
+// or an empty one if not configured.
+func getProviderConfig(data any) (ProviderConfig, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	if data == nil {
+		return ProviderConfig{}, diags
+	}
+
+	cfg, ok := data.(ProviderConfig)
+	if !ok {
+		diags.Append(diag.NewErrorDiagnostic(
+			"Invalid Provider Data",
+			fmt.Sprintf("Expected ProviderConfig, got %T", data),
+		))
+	}
+
+	return cfg, diags
+}
+
 func NewProvider() provider.Provider {
 	return &Provider{}
 }
 
+// NewProviderWithPool returns a function to create the provider,
+// which shares the hosts of the given pool.
+func NewProviderWithPool(pool *target.Pool) func() provider.Provider {
+	return func() provider.Provider {
+		return &Provider{
+			pool: pool,
+		}
+	}
+}
+
 func (p *Provider) Metadata(
 	ctx context.Context,
 	req provider.MetadataRequest,
@@ -41,6 +118,259 @@ func (p *Provider) Schema(
 	req provider.SchemaRequest,
 	resp *provider.SchemaResponse,
 ) {
+	resp.Schema = schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"install_dir": schema.StringAttribute{
+				Optional: true,
+				Description: `The directory to install the runtime and the artifact on the targets, 
+defaults to "/var/local/courier" on Linux, or "C:\ProgramData\courier" on Windows.`,
+			},
+			"authn": schema.SingleNestedAttribute{
+				Optional: true,
+				Description: `The default authentication for accessing the targets, 
+works if the target host does not specify its authn.`,
+				Attributes: map[string]schema.Attribute{
+					"type": schema.StringAttribute{
+						Optional: true,
+						Description: `The type to access the target, either "ssh" or "winrm", 
+defaults to "ssh".`,
+						Validators: []validator.String{
+							stringvalidator.OneOf("ssh", "winrm"),
+						},
+					},
+					"user": schema.StringAttribute{
+						Optional: true,
+						Description: `The user to authenticate when accessing the target, 
+defaults to "root".`,
+					},
+					"secret": schema.StringAttribute{
+						Optional:    true,
+						Description: hostAuthnSecretDescription,
+						Sensitive:   true,
+					},
+					"agent": schema.BoolAttribute{
+						Optional:    true,
+						Description: hostAuthnAgentDescription,
+					},
+					"passphrase": schema.StringAttribute{
+						Optional:    true,
+						Description: hostAuthnPassphraseDescription,
+						Sensitive:   true,
+					},
+					"certificate": schema.StringAttribute{
+						Optional:    true,
+						Description: hostAuthnCertificateDescription,
+					},
+					"password": schema.StringAttribute{
+						Optional:    true,
+						Description: hostAuthnPasswordDescription,
+						Sensitive:   true,
+					},
+					"become":   providerBecomeSchema(),
+					"kerberos": providerKerberosSchema(),
+				},
+			},
+			"proxies": schema.ListNestedAttribute{
+				Optional: true,
+				Description: `The default proxies before accessing the targets, 
+works if the target host does not specify its proxies.`,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"address": schema.StringAttribute{
+							Required: true,
+							Description: `The address to access the proxy, 
+in the form of [schema://](ip|dns)[:port].`,
+							Validators: []validator.String{
+								stringvalidator.LengthAtLeast(1),
+							},
+						},
+						"authn": schema.SingleNestedAttribute{
+							Required:    true,
+							Description: `The authentication for accessing the proxy.`,
+							Attributes: map[string]schema.Attribute{
+								"type": schema.StringAttribute{
+									Optional: true,
+									Description: `The type to access the proxy, either "ssh" or "proxy", 
+defaults to "proxy".`,
+									Validators: []validator.String{
+										stringvalidator.OneOf("ssh", "proxy"),
+									},
+								},
+								"user": schema.StringAttribute{
+									Optional:    true,
+									Description: proxyAuthnUserDescription,
+								},
+								"secret": schema.StringAttribute{
+									Optional:    true,
+									Description: proxyAuthnSecretDescription,
+									Sensitive:   true,
+								},
+								"passphrase": schema.StringAttribute{
+									Optional:    true,
+									Description: hostAuthnPassphraseDescription,
+									Sensitive:   true,
+								},
+								"certificate": schema.StringAttribute{
+									Optional:    true,
+									Description: proxyAuthnCertificateDescription,
+								},
+								"password": schema.StringAttribute{
+									Optional:    true,
+									Description: proxyAuthnPasswordDescription,
+									Sensitive:   true,
+								},
+							},
+						},
+						"host_key": providerHostKeySchema(),
+						"insecure": schema.BoolAttribute{
+							Optional: true,
+							Description: `Specify to access the proxy with insecure mode,
+which skips the host key verification.`,
+						},
+					},
+				},
+			},
+			"insecure": schema.BoolAttribute{
+				Optional: true,
+				Description: `Specify to access all targets with insecure mode,
+which skips the host key or the certificate verification.`,
+			},
+			"runtime": schema.SingleNestedAttribute{
+				Optional: true,
+				Description: `The default runtime source, 
+works if the runtime does not specify its source.`,
+				Attributes: map[string]schema.Attribute{
+					"source": schema.StringAttribute{
+						Required: true,
+						Description: `The source to fetch the runtime, 
+only support a git repository at present.`,
+					},
+					"authn": schema.SingleNestedAttribute{
+						Optional:    true,
+						Description: `The authentication for fetch the runtime.`,
+						Attributes: map[string]schema.Attribute{
+							"type": schema.StringAttribute{
+								Optional:    true,
+								Description: `The type for authentication, either "basic" or "bearer".`,
+								Validators: []validator.String{
+									stringvalidator.OneOf("basic", "bearer"),
+								},
+							},
+							"user": schema.StringAttribute{
+								Optional:    true,
+								Description: `The user for authentication.`,
+							},
+							"secret": schema.StringAttribute{
+								Required:    true,
+								Description: `The secret for authentication, either password or token.`,
+								Sensitive:   true,
+							},
+						},
+					},
+					"insecure": schema.BoolAttribute{
+						Optional:    true,
+						Description: `Specify to fetch the runtime with insecure mode.`,
+					},
+				},
+			},
+			"timeout": schema.StringAttribute{
+				Optional: true,
+				Description: `The default timeout of the operations, 
+in the form of a duration string, e.g. "30m", defaults to "10m".`,
+			},
+			"parallelism": schema.Int64Attribute{
+				Optional: true,
+				Description: `The maximum number of targets to operate at once, 
+no limit by default.`,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+		},
+	}
+}
+
+// providerBecomeSchema returns the schema of the privilege escalation.
+func providerBecomeSchema() schema.SingleNestedAttribute {
+	return schema.SingleNestedAttribute{
+		Optional:    true,
+		Description: hostBecomeDescription,
+		Attributes: map[string]schema.Attribute{
+			"method": schema.StringAttribute{
+				Optional:    true,
+				Description: hostBecomeMethodDescription,
+				Validators:  hostBecomeMethodValidators(),
+			},
+			"user": schema.StringAttribute{
+				Optional:    true,
+				Description: hostBecomeUserDescription,
+				Validators:  hostBecomeUserValidators(),
+			},
+			"password": schema.StringAttribute{
+				Optional:    true,
+				Description: hostBecomePasswordDescription,
+				Sensitive:   true,
+			},
+		},
+	}
+}
+
+// providerKerberosSchema returns the schema of the Kerberos authentication.
+func providerKerberosSchema() schema.SingleNestedAttribute {
+	return schema.SingleNestedAttribute{
+		Optional:    true,
+		Description: hostKerberosDescription,
+		Attributes: map[string]schema.Attribute{
+			"realm": schema.StringAttribute{
+				Required:    true,
+				Description: hostKerberosRealmDescription,
+				Validators:  hostKerberosRealmValidators(),
+			},
+			"krb5_conf": schema.StringAttribute{
+				Optional:    true,
+				Description: hostKerberosKrb5ConfDescription,
+			},
+			"keytab": schema.StringAttribute{
+				Optional:    true,
+				Description: hostKerberosKeytabDescription,
+				Validators:  hostKerberosKeytabValidators(),
+			},
+			"ccache": schema.StringAttribute{
+				Optional:    true,
+				Description: hostKerberosCCacheDescription,
+			},
+			"spn": schema.StringAttribute{
+				Optional:    true,
+				Description: hostKerberosSPNDescription,
+			},
+		},
+	}
+}
+
+// providerHostKeySchema returns the schema of the host key verification of the proxy.
+func providerHostKeySchema() schema.SingleNestedAttribute {
+	return schema.SingleNestedAttribute{
+		Optional:    true,
+		Description: hostKeyDescription("proxy"),
+		Attributes: map[string]schema.Attribute{
+			"known_hosts": schema.StringAttribute{
+				Optional:    true,
+				Description: hostKeyKnownHostsDescription,
+			},
+			"fingerprints": schema.ListAttribute{
+				Optional:    true,
+				ElementType: types.StringType,
+				Description: hostKeyFingerprintsDescription,
+			},
+			"trust_on_first_use": schema.BoolAttribute{
+				Optional: true,
+				Description: `Specify to trust the unknown host key on first use, 
+which is rejected without fingerprints, 
+as the provider cannot record the trusted fingerprint to pin the host key, 
+specify the proxies of the target in the resource to record instead.`,
+			},
+		},
+	}
 }
 
 func (p *Provider) Configure(
@@ -48,6 +378,62 @@ func (p *Provider) Configure(
 	req provider.ConfigureRequest,
 	resp *provider.ConfigureResponse,
 ) {
+	var cfg ProviderConfig
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &cfg)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if v := cfg.Timeout.ValueString(); v != "" {
+		if _, err := time.ParseDuration(v); err != nil {
+			resp.Diagnostics.Append(diag.NewAttributeErrorDiagnostic(
+				path.Root("timeout"),
+				"Invalid Timeout",
+				fmt.Sprintf("Cannot parse timeout: %v", err),
+			))
+
+			return
+		}
+	}
+
+	// Default the authentication types as the resource does.
+	if au := cfg.Authn; au != nil {
+		if au.Type.ValueString() == "" {
+			au.Type = types.StringValue("ssh")
+		}
+
+		if au.User.ValueString() == "" {
+			au.User = types.StringValue("root")
+		}
+	}
+
+	for i := range cfg.Proxies {
+		if cfg.Proxies[i].Authn.Type.ValueString() == "" {
+			cfg.Proxies[i].Authn.Type = types.StringValue("proxy")
+		}
+
+		// Reject trusting on first use,
+		// as the provider configuration cannot record the trusted fingerprint.
+		if cfg.Proxies[i].HostKey.Unpinned() {
+			resp.Diagnostics.Append(diag.NewAttributeErrorDiagnostic(
+				path.Root("proxies").AtListIndex(i).AtName("host_key").AtName("trust_on_first_use"),
+				"Unsupported Trust On First Use",
+				"Cannot record the trusted fingerprint in the provider, "+
+					"specify the fingerprints or the known_hosts to pin the host key, "+
+					"or specify the proxies of the target in the resource instead",
+			))
+		}
+	}
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	cfg.pool = p.pool
+
+	resp.ResourceData = cfg
+	resp.DataSourceData = cfg
 }
 
 func (p *Provider) DataSources(
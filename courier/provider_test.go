@@ -16,9 +16,11 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/multierr"
@@ -44,6 +46,37 @@ func TestProvider_metadata(t *testing.T) {
 	assert.Equal(t, resp.Version, version.Version)
 }
 
+func TestProviderConfig_GetTimeout(t *testing.T) {
+	cases := []struct {
+		name     string
+		given    types.String
+		expected time.Duration
+	}{
+		{
+			name:     "null",
+			given:    types.StringNull(),
+			expected: 10 * time.Minute,
+		},
+		{
+			name:     "invalid",
+			given:    types.StringValue("x"),
+			expected: 10 * time.Minute,
+		},
+		{
+			name:     "specified",
+			given:    types.StringValue("30m"),
+			expected: 30 * time.Minute,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual := ProviderConfig{Timeout: c.given}.GetTimeout()
+			assert.Equal(t, c.expected, actual)
+		})
+	}
+}
+
 var testAccProviderFactories = map[string]func() (tfprotov6.ProviderServer, error){
 	"courier": providerserver.NewProtocol6WithError(NewProvider()),
 }
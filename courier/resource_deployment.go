@@ -3,16 +3,31 @@ package courier
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"math"
+	"net"
+	"net/http"
 	"os"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf16"
 
+	"github.com/apparentlymart/go-shquot/shquot"
 	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
 	"github.com/hashicorp/terraform-plugin-framework-validators/float64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
 	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/mapvalidator"
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
@@ -20,6 +35,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/float64default"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listdefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapdefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/objectplanmodifier"
@@ -30,15 +46,28 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"go.uber.org/multierr"
 	"golang.org/x/sync/errgroup"
 
+	"github.com/seal-io/terraform-provider-courier/pkg/artifact"
 	"github.com/seal-io/terraform-provider-courier/pkg/runtime"
 	"github.com/seal-io/terraform-provider-courier/pkg/target"
+	"github.com/seal-io/terraform-provider-courier/utils/iox"
 	"github.com/seal-io/terraform-provider-courier/utils/osx"
 	"github.com/seal-io/terraform-provider-courier/utils/strx"
+	"github.com/seal-io/terraform-provider-courier/utils/wait"
 )
 
-var _ resource.Resource = (*ResourceDeployment)(nil)
+var (
+	_ resource.Resource                = (*ResourceDeployment)(nil)
+	_ resource.ResourceWithModifyPlan  = (*ResourceDeployment)(nil)
+	_ resource.ResourceWithImportState = (*ResourceDeployment)(nil)
+	_ resource.ResourceWithConfigure   = (*ResourceDeployment)(nil)
+)
+
+// deploymentIDRegexp matches the ID of the deployment,
+// which names the container and the directory on the target.
+var deploymentIDRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
 
 type (
 	ResourceDeployment struct {
@@ -48,13 +77,31 @@ type (
 		Strategy *ResourceDeploymentStrategy `tfsdk:"strategy"`
 		Timeouts timeouts.Value              `tfsdk:"timeouts"`
 
-		ID types.String `tfsdk:"id"`
+		Parallelism     types.Int64 `tfsdk:"parallelism"`
+		ContinueOnError types.Bool  `tfsdk:"continue_on_error"`
+
+		ID              types.String `tfsdk:"id"`
+		Slot            types.String `tfsdk:"slot"`
+		CanaryStartedAt types.String `tfsdk:"canary_started_at"`
+
+		// config is the provider configuration.
+		config ProviderConfig
+		// failures records the failed targets of the applying.
+		failures *deploymentFailures
 	}
 
 	ResourceDeploymentTarget struct {
-		Host DataSourceTargetHost `tfsdk:"host"`
-		OS   types.String         `tfsdk:"os"`
-		Arch types.String         `tfsdk:"arch"`
+		Host       DataSourceTargetHost `tfsdk:"host"`
+		OS         types.String         `tfsdk:"os"`
+		Arch       types.String         `tfsdk:"arch"`
+		InstallDir types.String         `tfsdk:"install_dir"`
+
+		Status      types.String `tfsdk:"status"`
+		Digest      types.String `tfsdk:"digest"`
+		StartedAt   types.String `tfsdk:"started_at"`
+		ArtifactURI types.String `tfsdk:"artifact_uri"`
+
+		ArtifactIdentity types.String `tfsdk:"artifact_identity"`
 	}
 
 	ResourceDeploymentArtifact struct {
@@ -64,6 +111,21 @@ type (
 		Envs    map[string]types.String `tfsdk:"envs"`
 		Volumes []types.String          `tfsdk:"volumes"`
 		Digest  types.String            `tfsdk:"digest"`
+
+		SecretEnvs map[string]types.String          `tfsdk:"secret_envs"`
+		Files      []ResourceDeploymentArtifactFile `tfsdk:"files"`
+
+		TrackDigest types.Bool   `tfsdk:"track_digest"`
+		FetchMode   types.String `tfsdk:"fetch_mode"`
+	}
+
+	ResourceDeploymentArtifactFile struct {
+		Path    types.String `tfsdk:"path"`
+		Content types.String `tfsdk:"content"`
+		Source  types.String `tfsdk:"source"`
+		Mode    types.String `tfsdk:"mode"`
+		Owner   types.String `tfsdk:"owner"`
+		Digest  types.String `tfsdk:"digest"`
 	}
 
 	ResourceDeploymentRuntime struct {
@@ -74,12 +136,39 @@ type (
 	}
 
 	ResourceDeploymentStrategy struct {
-		Type    types.String                       `tfsdk:"type"`
-		Rolling *ResourceDeploymentStrategyRolling `tfsdk:"rolling"`
+		Type        types.String                           `tfsdk:"type"`
+		Rolling     *ResourceDeploymentStrategyRolling     `tfsdk:"rolling"`
+		BlueGreen   *ResourceDeploymentStrategyBlueGreen   `tfsdk:"blue_green"`
+		Canary      *ResourceDeploymentStrategyCanary      `tfsdk:"canary"`
+		HealthCheck *ResourceDeploymentStrategyHealthCheck `tfsdk:"health_check"`
 	}
 
 	ResourceDeploymentStrategyRolling struct {
-		MaxSurge types.Float64 `tfsdk:"max_surge"`
+		MaxSurge          types.Float64 `tfsdk:"max_surge"`
+		RollbackOnFailure types.Bool    `tfsdk:"rollback_on_failure"`
+	}
+
+	ResourceDeploymentStrategyBlueGreen struct {
+		Ports         []types.Int64 `tfsdk:"ports"`
+		SwitchCommand types.String  `tfsdk:"switch_command"`
+	}
+
+	ResourceDeploymentStrategyCanary struct {
+		Count    types.Int64   `tfsdk:"count"`
+		Percent  types.Float64 `tfsdk:"percent"`
+		Promote  types.Bool    `tfsdk:"promote"`
+		BakeTime types.Int64   `tfsdk:"bake_time"`
+	}
+
+	ResourceDeploymentStrategyHealthCheck struct {
+		Type     types.String `tfsdk:"type"`
+		Port     types.Int64  `tfsdk:"port"`
+		Path     types.String `tfsdk:"path"`
+		Status   types.Int64  `tfsdk:"status"`
+		Command  types.String `tfsdk:"command"`
+		Interval types.Int64  `tfsdk:"interval"`
+		Timeout  types.Int64  `tfsdk:"timeout"`
+		Retries  types.Int64  `tfsdk:"retries"`
 	}
 )
 
@@ -92,396 +181,2621 @@ func (r *ResourceDeploymentArtifact) Equal(l ResourceDeploymentArtifact) bool {
 		return false
 	}
 
-	return r.Refer.URI.Equal(l.Refer.URI)
-}
+	if !r.Refer.URI.Equal(l.Refer.URI) ||
+		!r.Command.Equal(l.Command) ||
+		!r.Digest.Equal(l.Digest) {
+		return false
+	}
 
-func (r *ResourceDeployment) TargetsChanged(l ResourceDeployment) bool {
-	if len(r.Targets) != len(l.Targets) {
-		return true
+	if len(r.Ports) != len(l.Ports) ||
+		len(r.Envs) != len(l.Envs) ||
+		len(r.SecretEnvs) != len(l.SecretEnvs) ||
+		len(r.Volumes) != len(l.Volumes) {
+		return false
 	}
 
-	rtg := append(make([]ResourceDeploymentTarget, 0, len(r.Targets)), r.Targets...)
-	ltg := append(make([]ResourceDeploymentTarget, 0, len(l.Targets)), l.Targets...)
+	for i := range r.Ports {
+		if !r.Ports[i].Equal(l.Ports[i]) {
+			return false
+		}
+	}
 
-	sort.Slice(rtg, func(i, j int) bool {
-		return rtg[i].Host.Address.ValueString() < rtg[j].Host.Address.ValueString()
-	})
-	sort.Slice(ltg, func(i, j int) bool {
-		return ltg[i].Host.Address.ValueString() < ltg[j].Host.Address.ValueString()
-	})
+	for k := range r.Envs {
+		if !r.Envs[k].Equal(l.Envs[k]) {
+			return false
+		}
+	}
 
-	for i := range ltg {
-		if !rtg[i].Host.Address.Equal(ltg[i].Host.Address) {
-			return true
+	for k := range r.SecretEnvs {
+		if !r.SecretEnvs[k].Equal(l.SecretEnvs[k]) {
+			return false
 		}
 	}
 
-	return false
+	for i := range r.Volumes {
+		if !r.Volumes[i].Equal(l.Volumes[i]) {
+			return false
+		}
+	}
+
+	return r.EqualFiles(l)
 }
 
-func (r *ResourceDeployment) Apply(
-	ctx context.Context,
-	prevArt *ResourceDeploymentArtifact,
-) diag.Diagnostics {
-	deploy, diags := r.Reflect(ctx)
-	if diags.HasError() {
-		return diags
-	}
+// Identity returns the checksum of the fields compared by Equal,
+// which tells the artifact deployed on the target apart
+// even if the URI is the same, e.g. the digest or the envs changed.
+func (r ResourceDeploymentArtifact) Identity() string {
+	h := sha256.New()
 
-	diags.Append(deploy.Setup(ctx)...)
-	if diags.HasError() {
-		return diags
+	write := func(vs ...string) {
+		for i := range vs {
+			_, _ = io.WriteString(h, vs[i])
+			_, _ = h.Write([]byte{0})
+		}
 	}
 
-	// Rolling.
-	if r.Strategy != nil && r.Strategy.Type.ValueString() == "rolling" {
-		maxSurge := 0.3
-
-		if r.Strategy.Rolling != nil {
-			maxSurge = r.Strategy.Rolling.MaxSurge.ValueFloat64()
-			if maxSurge < 0.1 {
-				maxSurge = 0.3
-			}
+	writeMap := func(name string, m map[string]types.String) {
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
 		}
+		sort.Strings(keys)
 
-		step := int(math.Round(maxSurge * float64(len(deploy.Targets))))
-		if step == 0 {
-			step = 1
+		write(name)
+		for _, k := range keys {
+			write(k, m[k].String())
 		}
+	}
 
-		if step != len(deploy.Targets) {
-			for i, m := 0, len(deploy.Targets); i < m; {
-				j := i + step
-				if j > m {
-					j = m
-				}
+	write(r.Refer.URI.String(), r.Command.String(), r.Digest.String())
 
-				partialDeploy := *deploy
-				if i == m-1 {
-					partialDeploy.Targets = []DeploymentTarget{deploy.Targets[i]}
-				} else {
-					partialDeploy.Targets = append([]DeploymentTarget(nil), deploy.Targets[i:j]...)
-				}
+	write("ports")
+	for i := range r.Ports {
+		write(r.Ports[i].String())
+	}
 
-				if !prevArt.Equal(r.Artifact) {
-					diags.Append(partialDeploy.Stop(ctx)...)
-					if diags.HasError() {
-						return diags
-					}
-				}
+	writeMap("envs", r.Envs)
+	writeMap("secret_envs", r.SecretEnvs)
 
-				diags.Append(partialDeploy.Start(ctx)...)
-				if diags.HasError() {
-					return diags
-				}
+	write("volumes")
+	for i := range r.Volumes {
+		write(r.Volumes[i].String())
+	}
 
-				i = j
-			}
+	write("files")
+	for i := range r.Files {
+		f := r.Files[i]
+		write(f.Path.String(), f.Mode.String(), f.Owner.String(), f.Digest.String())
+	}
 
-			return diags
-		}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// EqualFiles returns true if the files of the given artifact are the same,
+// the changed files restart the deployment without replacing the artifact.
+func (r *ResourceDeploymentArtifact) EqualFiles(l ResourceDeploymentArtifact) bool {
+	if r == nil || len(r.Files) != len(l.Files) {
+		return false
 	}
 
-	// Recreate.
-	if !prevArt.Equal(r.Artifact) {
-		diags.Append(deploy.Stop(ctx)...)
-		if diags.HasError() {
-			return diags
+	for i := range r.Files {
+		if !r.Files[i].Path.Equal(l.Files[i].Path) ||
+			!r.Files[i].Mode.Equal(l.Files[i].Mode) ||
+			!r.Files[i].Owner.Equal(l.Files[i].Owner) ||
+			!r.Files[i].Digest.Equal(l.Files[i].Digest) {
+			return false
 		}
 	}
 
-	diags.Append(deploy.Start(ctx)...)
+	return true
+}
 
-	return diags
+// Read returns the inline content or the content of the local source.
+func (r ResourceDeploymentArtifactFile) Read() ([]byte, error) {
+	if !r.Content.IsNull() {
+		return []byte(r.Content.ValueString()), nil
+	}
+
+	return os.ReadFile(r.Source.ValueString())
 }
 
-func (r *ResourceDeployment) Release(
-	ctx context.Context,
-) diag.Diagnostics {
-	deploy, diags := r.Reflect(ctx)
-	if diags.HasError() {
-		return diags
+// Sum returns the digest of the file content, in form of sha256:checksum.
+func (r ResourceDeploymentArtifactFile) Sum() (string, error) {
+	bs, err := r.Read()
+	if err != nil {
+		return "", err
 	}
 
-	diags.Append(deploy.Cleanup(ctx)...)
+	s := sha256.Sum256(bs)
 
-	return diags
+	return "sha256:" + hex.EncodeToString(s[:]), nil
 }
 
-type (
-	DeploymentTarget struct {
-		target.Host
+// CarryDigest keeps the resolved digest of the given previous artifact
+// if the digest is not configured and the artifact refers to the same uri,
+// returns true if carried.
+func (r *ResourceDeploymentArtifact) CarryDigest(
+	config types.String,
+	prev ResourceDeploymentArtifact,
+) bool {
+	if !config.IsNull() || !r.Digest.IsUnknown() ||
+		prev.Digest.IsUnknown() || !r.Refer.URI.Equal(prev.Refer.URI) {
+		return false
+	}
 
-		RuntimeClass string
-		OS           string
-		Arch         string
+	r.Digest = prev.Digest
+
+	return true
+}
+
+// Resolve returns the live digest of the artifact from the refer.
+func (r ResourceDeploymentArtifact) Resolve(ctx context.Context) (string, error) {
+	p, err := r.Refer.Reflect(ctx)
+	if err != nil {
+		return "", fmt.Errorf("cannot reflect from refer: %w", err)
 	}
 
-	Deployment struct {
-		ID       string
-		Targets  []DeploymentTarget
-		Runtime  runtime.Source
-		Artifact ResourceDeploymentArtifact
+	s, err := p.State(ctx)
+	if err != nil {
+		return "", fmt.Errorf("cannot state from uri %s: %w", r.Refer.URI.ValueString(), err)
 	}
-)
 
-func (r *ResourceDeployment) Reflect(
+	if !s.Accessible || s.Digest == "" {
+		return "", fmt.Errorf("cannot resolve digest from uri %s", r.Refer.URI.ValueString())
+	}
+
+	return s.Digest, nil
+}
+
+// Fetch writes the artifact of the given platform to the given writer,
+// and verifies it with the digest.
+func (r ResourceDeploymentArtifact) Fetch(ctx context.Context, w io.Writer, platform string) error {
+	p, err := r.Refer.Reflect(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot reflect from refer: %w", err)
+	}
+
+	return p.Fetch(ctx, w, artifact.FetchOptions{
+		Digest:   r.Digest.ValueString(),
+		Platform: platform,
+	})
+}
+
+// artifactDigestTracker resolves the live digest of the artifact at planning
+// if tracking the digest and the digest is not configured,
+// which shows a diff if the artifact has been republished to the same uri.
+type artifactDigestTracker struct{}
+
+func (artifactDigestTracker) Description(context.Context) string {
+	return "Resolves the live digest of the artifact if tracking the digest."
+}
+
+func (m artifactDigestTracker) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
+
+func (artifactDigestTracker) PlanModifyString(
 	ctx context.Context,
-) (*Deployment, diag.Diagnostics) {
-	var diags diag.Diagnostics
+	req planmodifier.StringRequest,
+	resp *planmodifier.StringResponse,
+) {
+	// Skip if destroying or the digest is configured.
+	if req.Plan.Raw.IsNull() || !req.ConfigValue.IsNull() {
+		return
+	}
+
+	var art ResourceDeploymentArtifact
+
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("artifact"), &art)...)
+	if resp.Diagnostics.HasError() ||
+		!art.TrackDigest.ValueBool() || art.Refer.URI.IsUnknown() {
+		return
+	}
 
-	rt, err := r.Runtime.Reflect(ctx)
+	digest, err := art.Resolve(ctx)
 	if err != nil {
-		diags.Append(diag.NewAttributeErrorDiagnostic(
-			path.Root("runtime"),
-			"Invalid Runtime",
-			fmt.Sprintf("Cannot reflect: %v", err),
+		resp.Diagnostics.Append(diag.NewAttributeWarningDiagnostic(
+			req.Path,
+			"Untrackable Artifact Digest",
+			fmt.Sprintf("Cannot resolve the live digest of the artifact: %v", err),
 		))
 
-		return nil, diags
+		return
 	}
 
-	deploy := &Deployment{
-		ID:       r.ID.ValueString(),
-		Targets:  make([]DeploymentTarget, 0, len(r.Targets)),
-		Runtime:  rt,
-		Artifact: r.Artifact,
+	if digest == req.StateValue.ValueString() {
+		resp.PlanValue = req.StateValue
+		return
 	}
 
-	for i := range r.Targets {
-		host, err := r.Targets[i].Host.Reflect(ctx)
-		if err != nil {
-			diags.Append(diag.NewAttributeErrorDiagnostic(
-				path.Root("targets").AtListIndex(i).AtName("host"),
-				"Invalid Target Host",
-				fmt.Sprintf("Cannot reflect from host: %v", err),
-			))
+	tflog.Debug(ctx, "Artifact digest changed, planning to deploy again...",
+		map[string]any{
+			"uri":    art.Refer.URI.ValueString(),
+			"digest": digest,
+		})
 
-			continue
-		}
+	resp.PlanValue = types.StringValue(digest)
 
-		deploy.Targets = append(deploy.Targets, DeploymentTarget{
-			Host:         host,
-			RuntimeClass: r.Runtime.Class.ValueString(),
-			OS:           r.Targets[i].OS.ValueString(),
-			Arch:         r.Targets[i].Arch.ValueString(),
-		})
+	// Replace if the strategy is recreate.
+	if req.State.Raw.IsNull() || req.StateValue.IsNull() {
+		return
 	}
 
-	return deploy, diags
-}
+	var typ types.String
 
-func (t DeploymentTarget) Command() string {
-	suffix := "sh"
-	if t.OS == "windows" {
-		suffix = "ps1"
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx,
+		path.Root("strategy").AtName("type"), &typ)...)
+	switch typ.ValueString() {
+	case "rolling", "blue_green", "canary":
+	default:
+		resp.RequiresReplace = true
 	}
-
-	return fmt.Sprintf("/var/local/courier/runtime/%s/%s/service.%s",
-		t.RuntimeClass,
-		t.OS,
-		suffix)
 }
 
-func (d Deployment) Setup(ctx context.Context) diag.Diagnostics {
-	var (
-		diags diag.Diagnostics
-		tgts  = d.Targets
-		art   = d.Artifact
-	)
+// artifactFilePathRegex matches the relative path whose segments are neither "." nor "..".
+const artifactFilePathRegex = `^(?:[\w-][\w.-]*|\.[\w-][\w.-]*|\.\.[\w.-]+)` +
+	`(?:/(?:[\w-][\w.-]*|\.[\w-][\w.-]*|\.\.[\w.-]+))*$`
 
-	// Upload runtime.
-	{
-		g, ctx := errgroup.WithContext(ctx)
+// artifactFileDigester sums the content of the artifact file at planning,
+// which shows a diff if the local source has been changed.
+type artifactFileDigester struct{}
 
-		for i := range tgts {
-			t := tgts[i]
+func (artifactFileDigester) Description(context.Context) string {
+	return "Sums the content of the artifact file."
+}
 
-			g.Go(func() error {
-				// TODO, avoid upload runtime if already exists.
-				err := t.UploadDirectory(
-					ctx,
-					d.Runtime,
-					"/var/local/courier/runtime")
-				if err != nil {
-					return err
-				}
+func (m artifactFileDigester) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
 
-				if t.OS == "linux" {
-					output, err := t.ExecuteWithOutput(
-						ctx,
-						"chmod",
-						"a+x",
-						fmt.Sprintf("/var/local/courier/runtime/%s/linux/service.sh", t.RuntimeClass),
-					)
-					if err != nil {
-						tflog.Error(ctx, "cannot change service permission: "+string(output))
-						return err
-					}
-				}
+func (artifactFileDigester) PlanModifyString(
+	ctx context.Context,
+	req planmodifier.StringRequest,
+	resp *planmodifier.StringResponse,
+) {
+	// Skip if destroying.
+	if req.Plan.Raw.IsNull() {
+		return
+	}
 
-				return nil
-			})
-		}
+	var f ResourceDeploymentArtifactFile
 
-		if err := g.Wait(); err != nil {
-			diags.Append(diag.NewErrorDiagnostic(
-				"Cannot upload runtime",
-				fmt.Sprintf("Cannot upload runtime: %v", err),
-			))
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, req.Path.ParentPath(), &f)...)
+	if resp.Diagnostics.HasError() ||
+		f.Content.IsUnknown() || f.Source.IsUnknown() {
+		return
+	}
 
-			return diags
-		}
+	digest, err := f.Sum()
+	if err != nil {
+		resp.Diagnostics.Append(diag.NewAttributeErrorDiagnostic(
+			req.Path.ParentPath(),
+			"Unreadable Artifact File",
+			fmt.Sprintf("Cannot read the artifact file: %v", err),
+		))
+
+		return
 	}
 
-	// Upload artifact.
-	{
-		tmpDir := osx.TempDir("courier-")
-		defer func() { _ = os.RemoveAll(tmpDir) }()
+	resp.PlanValue = types.StringValue(digest)
+}
 
-		err := os.WriteFile( //nolint:gosec
-			fmt.Sprintf("%s/command", tmpDir),
-			[]byte(art.Command.ValueString()),
-			0o666,
-		)
-		if err != nil {
-			diags.Append(diag.NewErrorDiagnostic(
-				"Cannot prepare command",
-				fmt.Sprintf("Cannot write command: %v", err),
-			))
+// ResolveDigest resolves the digest of the artifact if not specified,
+// which is verified by the runtime,
+// and sums the files whose content is unknown at planning.
+func (r *ResourceDeployment) ResolveDigest(ctx context.Context) diag.Diagnostics {
+	var diags diag.Diagnostics
 
-			return diags
+	for i := range r.Artifact.Files {
+		if !r.Artifact.Files[i].Digest.IsUnknown() {
+			continue
 		}
 
-		var (
-			ports    = make([]int, 0, len(art.Ports))
-			portsBuf bytes.Buffer
-		)
-		for i := range art.Ports {
-			if art.Ports[i].IsNull() ||
-				art.Ports[i].IsUnknown() {
-				continue
-			}
-			ports = append(ports, int(art.Ports[i].ValueInt64()))
-		}
-		sort.Ints(ports)
-		for i := range ports {
-			_, _ = fmt.Fprintf(&portsBuf, "%d\n", ports[i])
-		}
-		err = os.WriteFile( //nolint:gosec
-			fmt.Sprintf("%s/ports", tmpDir),
-			portsBuf.Bytes(),
-			0o666)
+		digest, err := r.Artifact.Files[i].Sum()
 		if err != nil {
-			diags.Append(diag.NewErrorDiagnostic(
-				"Cannot prepare ports",
-				fmt.Sprintf("Cannot prepare ports: %v", err),
+			diags.Append(diag.NewAttributeErrorDiagnostic(
+				path.Root("artifact").AtName("files").AtListIndex(i),
+				"Unreadable Artifact File",
+				fmt.Sprintf("Cannot read the artifact file: %v", err),
 			))
 
 			return diags
 		}
 
-		var (
-			envs    = make([]string, 0, len(art.Envs))
+		r.Artifact.Files[i].Digest = types.StringValue(digest)
+	}
+
+	if !r.Artifact.Digest.IsUnknown() && !r.Artifact.Digest.IsNull() {
+		return diags
+	}
+
+	digest, err := r.Artifact.Resolve(ctx)
+	if err != nil {
+		diags.Append(diag.NewAttributeErrorDiagnostic(
+			path.Root("artifact").AtName("refer"),
+			"Unresolvable Artifact Digest",
+			fmt.Sprintf("Cannot resolve the digest of the artifact: %v", err),
+		))
+
+		return diags
+	}
+
+	r.Artifact.Digest = types.StringValue(digest)
+
+	return diags
+}
+
+// DefaultID returns the deterministic ID of the deployment,
+// which derives from the runtime class, the artifact and the target addresses.
+func (r *ResourceDeployment) DefaultID() string {
+	addrs := make([]string, 0, len(r.Targets))
+	for i := range r.Targets {
+		addrs = append(addrs, r.Targets[i].Host.Address.ValueString())
+	}
+
+	sort.Strings(addrs)
+
+	return strx.Sum(
+		r.Runtime.Class.ValueString(),
+		r.Artifact.Refer.URI.ValueString(),
+		strings.Join(addrs, ","))
+}
+
+// Claim checks the ID against the artifacts on the targets,
+// fails if the ID is in use and the given defaulted is true,
+// e.g. the replaced deployment is not released yet with create_before_destroy,
+// otherwise takes over the deployment in use.
+func (r *ResourceDeployment) Claim(
+	ctx context.Context,
+	defaulted bool,
+) diag.Diagnostics {
+	deploy, diags := r.Reflect(ctx)
+	if diags.HasError() {
+		return diags
+	}
+
+	defer func() { _ = deploy.Close() }()
+
+	inUse, err := deploy.Artifacts(ctx)
+	if err != nil {
+		diags.Append(diag.NewErrorDiagnostic(
+			"Unobservable Artifacts",
+			fmt.Sprintf("Cannot list the artifacts on the targets: %v", err),
+		))
+
+		return diags
+	}
+
+	id := r.ID.ValueString()
+
+	if !idInUse(id, inUse) {
+		return diags
+	}
+
+	if defaulted {
+		diags.Append(diag.NewAttributeErrorDiagnostic(
+			path.Root("id"),
+			"Deployment ID In Use",
+			fmt.Sprintf("The default deployment ID %s exists on the targets, "+
+				"e.g. the replaced deployment with create_before_destroy is not released yet, "+
+				"specify another id to deploy aside, "+
+				"or specify the id to take over the existing deployment.", id),
+		))
+
+		return diags
+	}
+
+	diags.Append(diag.NewAttributeWarningDiagnostic(
+		path.Root("id"),
+		"Deployment ID In Use",
+		fmt.Sprintf("The deployment %s exists on the targets and is taken over, "+
+			"which is removed if another resource releases it, "+
+			"e.g. the replaced one with create_before_destroy.", id),
+	))
+
+	return diags
+}
+
+// idInUse returns true if the given ID or any of its slots is in use.
+func idInUse(id string, inUse map[string]bool) bool {
+	for _, s := range []string{"", "blue", "green", "current"} {
+		n := id
+		if s != "" {
+			n += "-" + s
+		}
+
+		if inUse[n] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetInstallDirs sets the unknown install directories of the targets,
+// prefers the given install directory, or the default one of the target operating system,
+// returns true if any set.
+func (r *ResourceDeployment) SetInstallDirs(installDir types.String) (set bool) {
+	for i := range r.Targets {
+		tg := &r.Targets[i]
+		if !tg.InstallDir.IsUnknown() && !tg.InstallDir.IsNull() {
+			continue
+		}
+
+		switch {
+		case installDir.ValueString() != "":
+			tg.InstallDir = installDir
+		case !tg.OS.IsUnknown():
+			tg.InstallDir = types.StringValue(DefaultInstallDir(tg.OS.ValueString()))
+		default:
+			continue
+		}
+
+		set = true
+	}
+
+	return set
+}
+
+// GetParallelism returns the maximum number of targets to operate at once,
+// prefers the parallelism of the deployment, or the one of the provider,
+// returns 0 if no limit.
+func (r *ResourceDeployment) GetParallelism() int {
+	if n := r.Parallelism.ValueInt64(); n > 0 {
+		return int(n)
+	}
+
+	return r.config.GetParallelism()
+}
+
+// SlotID returns the ID of the deployment in the given slot,
+// or the ID of the deployment if the slot is blank.
+func (r *ResourceDeployment) SlotID(slot string) string {
+	if slot == "" {
+		return r.ID.ValueString()
+	}
+
+	return r.ID.ValueString() + "-" + slot
+}
+
+// Drifted returns true if the deployment on the target has been stopped,
+// removed or replaced since the last observation.
+func (r ResourceDeploymentTarget) Drifted(art ResourceDeploymentArtifact) bool {
+	switch r.Status.ValueString() {
+	case "stopped", "missing", "failed":
+		return true
+	}
+
+	// The pending target is on the previous artifact.
+	if r.Pending(art) {
+		return false
+	}
+
+	// Compare the digest if both are in the same algorithm.
+	digest := art.Digest
+	ea, _, _ := strings.Cut(digest.ValueString(), ":")
+	aa, _, _ := strings.Cut(r.Digest.ValueString(), ":")
+
+	return ea != "" && ea == aa &&
+		digest.ValueString() != r.Digest.ValueString()
+}
+
+// Moved returns true if the install directory of the target
+// is different from the given one.
+func (r ResourceDeploymentTarget) Moved(l ResourceDeploymentTarget) bool {
+	return !l.InstallDir.IsUnknown() && r.GetInstallDir() != l.GetInstallDir()
+}
+
+// Pending returns true if the target is not on the given artifact yet,
+// e.g. waiting for the canary promotion.
+func (r ResourceDeploymentTarget) Pending(art ResourceDeploymentArtifact) bool {
+	return r.ArtifactURI.ValueString() != "" && !r.On(art)
+}
+
+// On returns true if the target is on the given artifact,
+// which compares the URI only if the identity was not observed by the previous version.
+func (r ResourceDeploymentTarget) On(art ResourceDeploymentArtifact) bool {
+	if r.ArtifactIdentity.ValueString() == "" {
+		return r.ArtifactURI.ValueString() != "" &&
+			r.ArtifactURI.ValueString() == art.Refer.URI.ValueString()
+	}
+
+	return r.ArtifactIdentity.ValueString() == art.Identity()
+}
+
+// SetArtifact records the given artifact which the target is on.
+func (r *ResourceDeploymentTarget) SetArtifact(art ResourceDeploymentArtifact) {
+	r.ArtifactURI = art.Refer.URI
+	r.ArtifactIdentity = types.StringValue(art.Identity())
+}
+
+// State observes the targets whose status is unknown.
+func (r *ResourceDeployment) State(
+	ctx context.Context,
+) diag.Diagnostics {
+	var (
+		diags = make([]diag.Diagnostics, len(r.Targets))
+		g     errgroup.Group
+	)
+
+	if n := r.GetParallelism(); n > 0 {
+		g.SetLimit(n)
+	}
+
+	for i := range r.Targets {
+		if !r.Targets[i].Status.IsUnknown() {
+			continue
+		}
+
+		i := i
+		tg := &r.Targets[i]
+
+		g.Go(func() error {
+			s, err := tg.State(ctx, r.config, r.Runtime.Class.ValueString(), r.SlotID(r.Slot.ValueString()))
+			if err != nil {
+				diags[i].Append(diag.NewWarningDiagnostic(
+					"Unobservable Target",
+					fmt.Sprintf("Cannot state from address %s: %v",
+						tg.Host.Address.ValueString(), err),
+				))
+			}
+
+			tg.Status = types.StringValue(s.Status)
+			tg.Digest = types.StringNull()
+			tg.StartedAt = types.StringNull()
+
+			if s.Digest != "" {
+				tg.Digest = types.StringValue(s.Digest)
+			}
+
+			if s.StartedAt != "" {
+				tg.StartedAt = types.StringValue(s.StartedAt)
+			}
+
+			return nil
+		})
+	}
+
+	_ = g.Wait()
+
+	var rdiags diag.Diagnostics
+	for i := range diags {
+		rdiags.Append(diags[i]...)
+	}
+
+	return rdiags
+}
+
+func (r *ResourceDeployment) Apply(
+	ctx context.Context,
+	prevArt *ResourceDeploymentArtifact,
+) (diags diag.Diagnostics) {
+	r.failures = &deploymentFailures{}
+
+	var (
+		uris = make([]types.String, len(r.Targets))
+		ids  = make([]types.String, len(r.Targets))
+	)
+	for i := range r.Targets {
+		uris[i] = r.Targets[i].ArtifactURI
+		ids[i] = r.Targets[i].ArtifactIdentity
+	}
+
+	// Mark the failed targets, which keep the previous artifact and are applied again by the next applying.
+	defer func() {
+		for i := range r.Targets {
+			if r.Targets[i].ArtifactURI.IsUnknown() {
+				r.Targets[i].ArtifactURI = types.StringNull()
+			}
+
+			if r.Targets[i].ArtifactIdentity.IsUnknown() {
+				r.Targets[i].ArtifactIdentity = types.StringNull()
+			}
+
+			if !r.failures.has(r.Targets[i].Host.Address.ValueString()) {
+				continue
+			}
+
+			r.Targets[i].Status = types.StringValue("failed")
+			r.Targets[i].Digest = types.StringNull()
+			r.Targets[i].StartedAt = types.StringNull()
+			r.Targets[i].ArtifactURI = uris[i]
+			if uris[i].IsUnknown() {
+				r.Targets[i].ArtifactURI = types.StringNull()
+			}
+
+			r.Targets[i].ArtifactIdentity = ids[i]
+			if ids[i].IsUnknown() {
+				r.Targets[i].ArtifactIdentity = types.StringNull()
+			}
+		}
+	}()
+
+	// Canary.
+	if r.Strategy != nil && r.Strategy.Type.ValueString() == "canary" {
+		return r.applyCanary(ctx, prevArt)
+	}
+
+	diags = r.apply(ctx, prevArt)
+	if !diags.HasError() {
+		for i := range r.Targets {
+			r.Targets[i].SetArtifact(r.Artifact)
+		}
+	}
+
+	return diags
+}
+
+func (r *ResourceDeployment) apply(
+	ctx context.Context,
+	prevArt *ResourceDeploymentArtifact,
+) (diags diag.Diagnostics) {
+	// Blue/Green.
+	if r.Strategy != nil && r.Strategy.Type.ValueString() == "blue_green" {
+		return r.applyBlueGreen(ctx, prevArt)
+	}
+
+	deploy, diags := r.Reflect(ctx)
+	if diags.HasError() {
+		return diags
+	}
+
+	defer func() {
+		diags.Append(deploy.Failures()...)
+		_ = deploy.Close()
+	}()
+
+	// Rolling.
+	if r.Strategy != nil && r.Strategy.Type.ValueString() == "rolling" {
+		maxSurge := 0.3
+
+		if r.Strategy.Rolling != nil {
+			maxSurge = r.Strategy.Rolling.MaxSurge.ValueFloat64()
+			if maxSurge < 0.1 {
+				maxSurge = 0.3
+			}
+		}
+
+		step := int(math.Round(maxSurge * float64(len(deploy.Targets))))
+		if step == 0 {
+			step = 1
+		}
+
+		if step != len(deploy.Targets) {
+			var (
+				touched []DeploymentTarget
+				fresh   = map[string]bool{}
+			)
+
+			// Record the targets not on any artifact yet, which are cleaned up instead of rolled back.
+			for i := range r.Targets {
+				if r.Targets[i].ArtifactURI.ValueString() == "" {
+					fresh[r.Targets[i].Host.Address.ValueString()] = true
+				}
+			}
+
+			for i, m := 0, len(deploy.Targets); i < m; {
+				j := i + step
+				if j > m {
+					j = m
+				}
+
+				partialDeploy := *deploy
+				if i == m-1 {
+					partialDeploy.Targets = []DeploymentTarget{deploy.Targets[i]}
+				} else {
+					partialDeploy.Targets = append([]DeploymentTarget(nil), deploy.Targets[i:j]...)
+				}
+
+				touched = append(touched, partialDeploy.Targets...)
+
+				diags.Append(r.applyBatch(ctx, partialDeploy, prevArt)...)
+				if diags.HasError() {
+					if r.Strategy.Rolling != nil && r.Strategy.Rolling.RollbackOnFailure.ValueBool() {
+						diags.Append(r.rollback(ctx, *deploy, touched, prevArt, fresh)...)
+					}
+
+					return diags
+				}
+
+				for k := i; k < j; k++ {
+					r.Targets[k].SetArtifact(r.Artifact)
+				}
+
+				i = j
+			}
+
+			return diags
+		}
+	}
+
+	diags.Append(deploy.Setup(ctx)...)
+	if diags.HasError() {
+		return diags
+	}
+
+	// Recreate.
+	if !prevArt.Equal(r.Artifact) {
+		diags.Append(deploy.Stop(ctx)...)
+		if diags.HasError() {
+			return diags
+		}
+	}
+
+	diags.Append(deploy.Start(ctx)...)
+	if diags.HasError() {
+		return diags
+	}
+
+	if r.Strategy != nil && r.Strategy.HealthCheck != nil {
+		diags.Append(deploy.Wait(ctx, r.Strategy.HealthCheck)...)
+	}
+
+	return diags
+}
+
+// Failed returns true if any target failed in the last applying.
+func (r *ResourceDeployment) Failed() bool {
+	for i := range r.Targets {
+		if r.Targets[i].Status.ValueString() == "failed" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// applyBatch sets up, restarts and checks the given batch of the rolling strategy.
+func (r *ResourceDeployment) applyBatch(
+	ctx context.Context,
+	deploy Deployment,
+	prevArt *ResourceDeploymentArtifact,
+) diag.Diagnostics {
+	diags := deploy.Setup(ctx)
+	if diags.HasError() {
+		return diags
+	}
+
+	if !prevArt.Equal(r.Artifact) {
+		diags.Append(deploy.Stop(ctx)...)
+		if diags.HasError() {
+			return diags
+		}
+	}
+
+	diags.Append(deploy.Start(ctx)...)
+	if diags.HasError() {
+		return diags
+	}
+
+	// Gate the next batch.
+	if hc := r.Strategy.HealthCheck; hc != nil {
+		diags.Append(deploy.Wait(ctx, hc)...)
+	}
+
+	return diags
+}
+
+// rollback puts the given touched targets back on the previous artifact,
+// or cleans up the touched targets which were not on any artifact before, e.g. creating,
+// and reports which targets were rolled back.
+//
+// The failed targets are rolled back too,
+// even if they are skipped by the applying with continue on error.
+func (r *ResourceDeployment) rollback(
+	ctx context.Context,
+	deploy Deployment,
+	touched []DeploymentTarget,
+	prevArt *ResourceDeploymentArtifact,
+	fresh map[string]bool,
+) diag.Diagnostics {
+	var (
+		diags             diag.Diagnostics
+		cleaned, restored []DeploymentTarget
+	)
+
+	for _, t := range touched {
+		switch {
+		case prevArt == nil || fresh[t.Address]:
+			cleaned = append(cleaned, t)
+		case !prevArt.Equal(r.Artifact):
+			restored = append(restored, t)
+		}
+	}
+
+	index := make(map[string]int, len(r.Targets))
+	for i := range r.Targets {
+		index[r.Targets[i].Host.Address.ValueString()] = i
+	}
+
+	deploy.failures = &deploymentFailures{}
+
+	// Clean up.
+	if len(cleaned) != 0 {
+		d := deploy
+		d.Targets = cleaned
+
+		addrs := deploymentAddresses(cleaned)
+
+		tflog.Debug(ctx, "Rolling update failed, cleaning up...",
+			map[string]any{
+				"targets": addrs,
+			})
+
+		ds := d.Cleanup(ctx)
+		diags.Append(ds...)
+
+		if ds.HasError() {
+			diags.Append(diag.NewErrorDiagnostic(
+				"Cannot clean up",
+				fmt.Sprintf("Cannot clean up targets %s",
+					strings.Join(addrs, ", ")),
+			))
+		} else {
+			for _, addr := range addrs {
+				i, ok := index[addr]
+				if !ok || deploy.failures.has(addr) {
+					continue
+				}
+
+				r.Targets[i].Status = types.StringValue("missing")
+				r.Targets[i].Digest = types.StringNull()
+				r.Targets[i].StartedAt = types.StringNull()
+				r.Targets[i].ArtifactURI = types.StringNull()
+				r.Targets[i].ArtifactIdentity = types.StringNull()
+			}
+
+			diags.Append(diag.NewErrorDiagnostic(
+				"Cleaned up",
+				fmt.Sprintf("Cleaned up targets %s which were not deployed before",
+					strings.Join(addrs, ", ")),
+			))
+		}
+	}
+
+	// Restore.
+	if len(restored) != 0 {
+		d := deploy
+		d.Targets = restored
+		d.Artifact = *prevArt
+
+		addrs := deploymentAddresses(restored)
+
+		tflog.Debug(ctx, "Rolling update failed, rolling back...",
+			map[string]any{
+				"targets": addrs,
+			})
+
+		ds := d.Setup(ctx)
+		if !ds.HasError() {
+			ds.Append(d.Stop(ctx)...)
+		}
+		if !ds.HasError() {
+			ds.Append(d.Start(ctx)...)
+		}
+		diags.Append(ds...)
+
+		if ds.HasError() {
+			diags.Append(diag.NewErrorDiagnostic(
+				"Cannot roll back",
+				fmt.Sprintf("Cannot roll back targets %s to the previous artifact",
+					strings.Join(addrs, ", ")),
+			))
+		} else {
+			for _, addr := range addrs {
+				i, ok := index[addr]
+				if !ok || deploy.failures.has(addr) {
+					continue
+				}
+
+				r.Targets[i].SetArtifact(*prevArt)
+			}
+
+			diags.Append(diag.NewErrorDiagnostic(
+				"Rolled back",
+				fmt.Sprintf("Rolled back targets %s to the previous artifact %s",
+					strings.Join(addrs, ", "), prevArt.Refer.URI.ValueString()),
+			))
+		}
+	}
+
+	diags.Append(deploy.Failures()...)
+
+	return diags
+}
+
+// deploymentAddresses returns the addresses of the given targets.
+func deploymentAddresses(ts []DeploymentTarget) []string {
+	addrs := make([]string, len(ts))
+	for i := range ts {
+		addrs[i] = ts[i].Address
+	}
+
+	return addrs
+}
+
+// applyCanary deploys the artifact to the canary targets first and pauses,
+// the rest targets are deployed by the next applying after promoted.
+func (r *ResourceDeployment) applyCanary(
+	ctx context.Context,
+	prevArt *ResourceDeploymentArtifact,
+) (diags diag.Diagnostics) {
+	c := r.Strategy.Canary
+
+	if c == nil {
+		c = &ResourceDeploymentStrategyCanary{}
+	}
+
+	deploy, diags := r.Reflect(ctx)
+	if diags.HasError() {
+		return diags
+	}
+
+	defer func() {
+		diags.Append(deploy.Failures()...)
+		_ = deploy.Close()
+	}()
+
+	var (
+		updated []int
+		pending []int
+		paused  bool
+	)
+
+	for i := range r.Targets {
+		if r.Targets[i].On(r.Artifact) {
+			updated = append(updated, i)
+		} else {
+			pending = append(pending, i)
+		}
+
+		paused = paused || r.Targets[i].Pending(r.Artifact)
+	}
+
+	// Apply all targets if creating or no target is on the previous artifact,
+	// e.g. the new targets or the drifted targets.
+	if prevArt == nil || !paused {
+		diags.Append(r.applyBatch(ctx, *deploy, prevArt)...)
+		if diags.HasError() {
+			return diags
+		}
+
+		for i := range r.Targets {
+			r.Targets[i].SetArtifact(r.Artifact)
+		}
+
+		r.CanaryStartedAt = types.StringNull()
+
+		return diags
+	}
+
+	n := int(c.Count.ValueInt64())
+	if n <= 0 {
+		percent := c.Percent.ValueFloat64()
+		if percent <= 0 {
+			percent = 0.1
+		}
+
+		n = int(math.Ceil(percent * float64(len(r.Targets))))
+	}
+
+	if n < 1 {
+		n = 1
+	}
+
+	var batch []int
+
+	switch {
+	case len(updated) < n:
+		batch = pending
+		if len(batch) > n-len(updated) {
+			batch = batch[:n-len(updated)]
+		}
+	case r.promoted(ctx, *deploy, updated):
+		batch = pending
+	}
+
+	if len(batch) != 0 {
+		tflog.Debug(ctx, "Deploying canary targets...",
+			map[string]any{
+				"targets": len(batch),
+			})
+
+		partialDeploy := *deploy
+		partialDeploy.Targets = make([]DeploymentTarget, 0, len(batch))
+
+		for _, i := range batch {
+			partialDeploy.Targets = append(partialDeploy.Targets, deploy.Targets[i])
+		}
+
+		// Stop the previous artifact on the pending targets.
+		diags.Append(r.applyBatch(ctx, partialDeploy, nil)...)
+		if diags.HasError() {
+			return diags
+		}
+
+		for _, i := range batch {
+			r.Targets[i].SetArtifact(r.Artifact)
+		}
+	}
+
+	switch {
+	case len(pending) == len(batch):
+		r.CanaryStartedAt = types.StringNull()
+	case r.CanaryStartedAt.ValueString() == "":
+		r.CanaryStartedAt = types.StringValue(time.Now().UTC().Format(time.RFC3339))
+	}
+
+	return diags
+}
+
+// promoted returns true if the canary can be promoted,
+// either promoting manually or baking enough time with healthy canary targets,
+// the canary must be paused by a previous applying.
+func (r *ResourceDeployment) promoted(
+	ctx context.Context,
+	deploy Deployment,
+	updated []int,
+) bool {
+	c := r.Strategy.Canary
+	if c == nil || r.CanaryStartedAt.ValueString() == "" {
+		return false
+	}
+
+	if c.Promote.ValueBool() {
+		return true
+	}
+
+	// Holding the promotion is not a failure.
+	deploy.failures = &deploymentFailures{}
+
+	bake := time.Duration(c.BakeTime.ValueInt64()) * time.Second
+	if bake <= 0 {
+		return false
+	}
+
+	startedAt, err := time.Parse(time.RFC3339, r.CanaryStartedAt.ValueString())
+	if err != nil || time.Since(startedAt) < bake {
+		return false
+	}
+
+	if hc := r.Strategy.HealthCheck; hc != nil {
+		partialDeploy := deploy
+		partialDeploy.Targets = make([]DeploymentTarget, 0, len(updated))
+
+		for _, i := range updated {
+			partialDeploy.Targets = append(partialDeploy.Targets, deploy.Targets[i])
+		}
+
+		if diags := partialDeploy.Wait(ctx, hc); diags.HasError() {
+			tflog.Warn(ctx, "Canary targets are unhealthy, holding the promotion...")
+			return false
+		}
+	}
+
+	return true
+}
+
+// applyBlueGreen deploys the artifact to the idle slot beside the active one,
+// switches to the idle slot once it is running, and then cleans up the previous slot.
+func (r *ResourceDeployment) applyBlueGreen(
+	ctx context.Context,
+	prevArt *ResourceDeploymentArtifact,
+) (diags diag.Diagnostics) {
+	var (
+		bg   = r.Strategy.BlueGreen
+		prev = r.Slot.ValueString()
+	)
+
+	if bg == nil || len(bg.Ports) != len(r.Artifact.Ports) {
+		return diag.Diagnostics{
+			diag.NewAttributeErrorDiagnostic(
+				path.Root("strategy").AtName("blue_green").AtName("ports"),
+				"Invalid Blue/Green Ports",
+				"The blue/green ports must correspond to the artifact ports one by one",
+			),
+		}
+	}
+
+	deploy, diags := r.Reflect(ctx)
+	if diags.HasError() {
+		return diags
+	}
+
+	defer func() {
+		diags.Append(deploy.Failures()...)
+		_ = deploy.Close()
+	}()
+
+	// Apply to the active slot if the artifact is not changed,
+	// e.g. the new targets or the drifted targets.
+	if prev != "" && prevArt.Equal(r.Artifact) {
+		active := deploy.InSlot(r.SlotID(prev), prev, bg)
+
+		diags.Append(active.Setup(ctx)...)
+		if diags.HasError() {
+			return diags
+		}
+
+		diags.Append(active.Start(ctx)...)
+		if diags.HasError() {
+			return diags
+		}
+
+		diags.Append(active.Wait(ctx, r.Strategy.HealthCheck)...)
+		if diags.HasError() {
+			return diags
+		}
+
+		diags.Append(active.Switch(ctx, r.SlotID("current"), bg.SwitchCommand.ValueString())...)
+
+		return diags
+	}
+
+	next := "blue"
+	if prev == "blue" {
+		next = "green"
+	}
+
+	idle := deploy.InSlot(r.SlotID(next), next, bg)
+
+	tflog.Debug(ctx, "Deploying to the idle slot...",
+		map[string]any{
+			"slot": next,
+		})
+
+	// Drop the idle slot if failed before switching.
+	diags.Append(func() (diags diag.Diagnostics) {
+		diags.Append(idle.Setup(ctx)...)
+		if diags.HasError() {
+			return diags
+		}
+
+		diags.Append(idle.Start(ctx)...)
+		if diags.HasError() {
+			return diags
+		}
+
+		return idle.Wait(ctx, r.Strategy.HealthCheck)
+	}()...)
+	if diags.HasError() {
+		tflog.Debug(ctx, "Idle slot failed, rolling back...",
+			map[string]any{
+				"slot": next,
+			})
+
+		diags.Append(idle.Cleanup(ctx)...)
+
+		// All targets stay on the active slot.
+		r.failures = &deploymentFailures{}
+
+		return diags
+	}
+
+	diags.Append(idle.Switch(ctx, r.SlotID("current"), bg.SwitchCommand.ValueString())...)
+	if diags.HasError() {
+		tflog.Debug(ctx, "Switching failed, rolling back...",
+			map[string]any{
+				"slot": next,
+			})
+
+		// Switch all targets back to the active slot,
+		// including the failed ones, which may have switched partway.
+		idle.failures = &deploymentFailures{}
+
+		if prev != "" {
+			active := deploy.InSlot(r.SlotID(prev), prev, bg)
+			active.failures = idle.failures
+			diags.Append(active.Switch(ctx, r.SlotID("current"), bg.SwitchCommand.ValueString())...)
+		} else {
+			diags.Append(idle.Unswitch(ctx, r.SlotID("current"))...)
+		}
+
+		diags.Append(idle.Cleanup(ctx)...)
+
+		// All targets stay on the active slot.
+		r.failures = &deploymentFailures{}
+
+		return diags
+	}
+
+	r.Slot = types.StringValue(next)
+
+	// Clean up the previous slot.
+	if prev != "" {
+		diags.Append(deploy.InSlot(r.SlotID(prev), prev, bg).Cleanup(ctx)...)
+	}
+
+	return diags
+}
+
+func (r *ResourceDeployment) Release(
+	ctx context.Context,
+) (diags diag.Diagnostics) {
+	deploy, diags := r.Reflect(ctx)
+	if diags.HasError() {
+		return diags
+	}
+
+	defer func() {
+		diags.Append(deploy.Failures()...)
+		_ = deploy.Close()
+	}()
+
+	diags.Append(deploy.Cleanup(ctx)...)
+
+	if r.Slot.ValueString() != "" {
+		diags.Append(deploy.Unswitch(ctx, r.SlotID("current"))...)
+	}
+
+	return diags
+}
+
+type (
+	DeploymentTarget struct {
+		target.Host
+
+		Address      string
+		RuntimeClass string
+		OS           string
+		Arch         string
+		InstallDir   string
+	}
+
+	Deployment struct {
+		ID       string
+		Targets  []DeploymentTarget
+		Runtime  runtime.Source
+		Artifact ResourceDeploymentArtifact
+
+		// RuntimeClass and RuntimeSource are persisted along with the artifact,
+		// which allows importing the deployment from the target.
+		RuntimeClass  string
+		RuntimeSource string
+
+		// Parallelism limits the number of targets to operate at once,
+		// no limit if not positive.
+		Parallelism int
+		// ContinueOnError keeps operating the other targets if any target fails,
+		// the failed targets are skipped in the following operations.
+		ContinueOnError bool
+
+		failures *deploymentFailures
+
+		// Slot is the blue/green slot of the deployment, if any.
+		Slot string
+		// PublishPorts publishes the artifact ports to other ports one by one,
+		// e.g. the ports of the green slot.
+		PublishPorts []int
+	}
+
+	DeploymentTargetStatus struct {
+		Status    string
+		Digest    string
+		StartedAt string
+	}
+)
+
+func (r *ResourceDeployment) Reflect(
+	ctx context.Context,
+) (*Deployment, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	rt, err := r.Runtime.Reflect(ctx, r.config)
+	if err != nil {
+		diags.Append(diag.NewAttributeErrorDiagnostic(
+			path.Root("runtime"),
+			"Invalid Runtime",
+			fmt.Sprintf("Cannot reflect: %v", err),
+		))
+
+		return nil, diags
+	}
+
+	deploy := &Deployment{
+		ID:       r.SlotID(r.Slot.ValueString()),
+		Targets:  make([]DeploymentTarget, 0, len(r.Targets)),
+		Runtime:  rt,
+		Artifact: r.Artifact,
+
+		RuntimeClass:    r.Runtime.Class.ValueString(),
+		RuntimeSource:   r.Runtime.Source.ValueString(),
+		Parallelism:     r.GetParallelism(),
+		ContinueOnError: r.ContinueOnError.ValueBool(),
+		failures:        r.failures,
+	}
+
+	if deploy.failures == nil {
+		deploy.failures = &deploymentFailures{}
+	}
+
+	for i := range r.Targets {
+		host, err := r.Targets[i].Host.Reflect(ctx, r.config)
+		if err != nil {
+			diags.Append(diag.NewAttributeErrorDiagnostic(
+				path.Root("targets").AtListIndex(i).AtName("host"),
+				"Invalid Target Host",
+				fmt.Sprintf("Cannot reflect from host: %v", err),
+			))
+
+			continue
+		}
+
+		deploy.Targets = append(deploy.Targets, DeploymentTarget{
+			Host:         host,
+			Address:      r.Targets[i].Host.Address.ValueString(),
+			RuntimeClass: r.Runtime.Class.ValueString(),
+			OS:           r.Targets[i].OS.ValueString(),
+			Arch:         r.Targets[i].Arch.ValueString(),
+			InstallDir:   r.Targets[i].GetInstallDir(),
+		})
+	}
+
+	if diags.HasError() {
+		_ = deploy.Close()
+		return nil, diags
+	}
+
+	return deploy, diags
+}
+
+// Artifacts returns the names of the artifacts on all targets.
+func (d Deployment) Artifacts(ctx context.Context) (map[string]bool, error) {
+	var (
+		names = make([][]string, len(d.Targets))
+		g     errgroup.Group
+	)
+
+	if d.Parallelism > 0 {
+		g.SetLimit(d.Parallelism)
+	}
+
+	for i := range d.Targets {
+		i := i
+		t := d.Targets[i]
+
+		g.Go(func() error {
+			dir := strings.TrimRight(t.InstallDir, `/\`) + "/artifact"
+
+			cmd, args := "sh", []string{"-c", shquot.POSIXShell([]string{"ls", "-1", dir}) + ` 2>/dev/null || true`}
+			if t.OS == "windows" {
+				cmd, args = powershellCommand(fmt.Sprintf(
+					`Get-ChildItem -Name -Path %s -ErrorAction SilentlyContinue`,
+					powershellQuote(strings.ReplaceAll(dir, "/", "\\"))))
+			}
+
+			output, err := t.ExecuteWithOutput(ctx, cmd, args...)
+			if err != nil {
+				return fmt.Errorf("list on %s: %w", t.Address, err)
+			}
+
+			names[i] = strings.Fields(string(output))
+
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	r := map[string]bool{}
+
+	for i := range names {
+		for j := range names[i] {
+			r[names[i][j]] = true
+		}
+	}
+
+	return r, nil
+}
+
+// Close closes the hosts of all targets.
+func (d Deployment) Close() (err error) {
+	for i := range d.Targets {
+		err = multierr.Append(err, d.Targets[i].Close())
+	}
+
+	return err
+}
+
+// DefaultInstallDir returns the default install directory of the given operating system.
+func DefaultInstallDir(osName string) string {
+	if osName == "windows" {
+		return `C:\ProgramData\courier`
+	}
+
+	return "/var/local/courier"
+}
+
+// GetInstallDir returns the install directory of the target,
+// or the default one of the target operating system if not set.
+func (r ResourceDeploymentTarget) GetInstallDir() string {
+	if v := r.InstallDir.ValueString(); v != "" {
+		return v
+	}
+
+	return DefaultInstallDir(r.OS.ValueString())
+}
+
+// RuntimeDir returns the directory of the runtime on the target,
+// the runtime scripts derive the artifact directory from it.
+func (t DeploymentTarget) RuntimeDir() string {
+	return strings.TrimRight(t.InstallDir, `/\`) + "/runtime"
+}
+
+// ArtifactDir returns the directory of the given artifact on the target.
+func (t DeploymentTarget) ArtifactDir(id string) string {
+	return strings.TrimRight(t.InstallDir, `/\`) + "/artifact/" + id
+}
+
+func (t DeploymentTarget) Command() string {
+	suffix := "sh"
+	if t.OS == "windows" {
+		suffix = "ps1"
+	}
+
+	return fmt.Sprintf("%s/%s/%s/service.%s",
+		t.RuntimeDir(),
+		t.RuntimeClass,
+		t.OS,
+		suffix)
+}
+
+// RuntimeManifest returns the manifest of the runtime on the target,
+// or an empty manifest if not found.
+func (t DeploymentTarget) RuntimeManifest(ctx context.Context) runtime.Manifest {
+	rd, err := t.DownloadFile(ctx, t.RuntimeDir()+"/"+runtime.ManifestName)
+	if err != nil {
+		return runtime.Manifest{}
+	}
+
+	defer func() { _ = rd.Close() }()
+
+	m, err := runtime.ParseManifest(rd)
+	if err != nil {
+		tflog.Warn(ctx, "cannot parse runtime manifest: "+err.Error())
+		return runtime.Manifest{}
+	}
+
+	return m
+}
+
+// Pushed returns the checksum of the artifact pushed to the target before,
+// or blank if the pushed artifact is not of the given digest.
+func (t DeploymentTarget) Pushed(ctx context.Context, id, digest string) string {
+	rd, err := t.DownloadFile(ctx, t.ArtifactDir(id)+"/pushed")
+	if err != nil {
+		return ""
+	}
+
+	defer func() { _ = rd.Close() }()
+
+	bs, err := io.ReadAll(rd)
+	if err != nil {
+		return ""
+	}
+
+	refer, pushed, ok := strings.Cut(strings.TrimSpace(string(bs)), " ")
+	if !ok || refer != digest {
+		return ""
+	}
+
+	return pushed
+}
+
+func (r *ResourceDeploymentTarget) State(
+	ctx context.Context,
+	cfg ProviderConfig,
+	runtimeClass, id string,
+) (DeploymentTargetStatus, error) {
+	s := DeploymentTargetStatus{
+		Status: "unknown",
+	}
+
+	h, err := r.Host.Reflect(ctx, cfg)
+	if err != nil {
+		return s, fmt.Errorf("cannot reflect from host: %w", err)
+	}
+
+	defer func() { _ = h.Close() }()
+
+	t := DeploymentTarget{
+		Host:         h,
+		RuntimeClass: runtimeClass,
+		OS:           r.OS.ValueString(),
+		Arch:         r.Arch.ValueString(),
+		InstallDir:   r.GetInstallDir(),
+	}
+
+	output, err := t.ExecuteWithOutput(target.WithBecome(ctx), t.Command(), "state", id)
+	if err != nil {
+		tflog.Error(ctx, "cannot execute state: "+string(output))
+		return s, err
+	}
+
+	return parseDeploymentTargetStatus(output), nil
+}
+
+// parseDeploymentTargetStatus parses the output of the state stage,
+// which is in form of key=value per line.
+func parseDeploymentTargetStatus(output []byte) DeploymentTargetStatus {
+	s := DeploymentTargetStatus{
+		Status: "unknown",
+	}
+
+	for _, line := range strings.Split(string(output), "\n") {
+		k, v, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			continue
+		}
+
+		switch k {
+		case "status":
+			s.Status = v
+		case "digest":
+			s.Digest = v
+		case "started_at":
+			t, err := time.Parse(time.RFC3339Nano, v)
+			if err != nil {
+				continue
+			}
+
+			s.StartedAt = t.UTC().Format(time.RFC3339)
+		}
+	}
+
+	return s
+}
+
+func (d Deployment) Setup(ctx context.Context) diag.Diagnostics {
+	var (
+		diags  diag.Diagnostics
+		art    = d.Artifact
+		pushed map[string]deploymentFetched
+		reused = map[string]string{}
+	)
+
+	// Resolve the digest of the artifact deployed before the digest was recorded.
+	if art.Digest.ValueString() == "" {
+		digest, err := art.Resolve(ctx)
+		if err != nil {
+			diags.Append(diag.NewErrorDiagnostic(
+				"Cannot prepare artifact",
+				fmt.Sprintf("Cannot resolve the digest of the artifact: %v", err),
+			))
+
+			return diags
+		}
+
+		art.Digest = types.StringValue(digest)
+	}
+
+	// Upload runtime.
+	{
+		mf, err := runtime.GetManifest(d.Runtime)
+		if err != nil {
+			diags.Append(diag.NewErrorDiagnostic(
+				"Cannot prepare runtime",
+				fmt.Sprintf("Cannot get runtime manifest: %v", err),
+			))
+
+			return diags
+		}
+
+		err = d.each(ctx, "setup", func(ctx context.Context, t DeploymentTarget) error {
+			// Only upload the changed files.
+			changed := mf.Diff(t.RuntimeManifest(ctx))
+			if len(changed) != 0 {
+				tflog.Debug(ctx, "Runtime changed, uploading...",
+					map[string]any{
+						"files": len(changed),
+					})
+
+				err := t.UploadDirectory(
+					ctx,
+					runtime.FilterSource(d.Runtime, changed),
+					t.RuntimeDir(),
+					uploadProgress(ctx, t, "runtime"))
+				if err != nil {
+					return err
+				}
+
+				err = t.UploadFile(
+					ctx,
+					bytes.NewReader(mf.Bytes()),
+					t.RuntimeDir()+"/"+runtime.ManifestName)
+				if err != nil {
+					return err
+				}
+			}
+
+			if t.OS == "linux" {
+				output, err := t.ExecuteWithOutput(
+					ctx,
+					"chmod",
+					"a+x",
+					t.Command(),
+				)
+				if err != nil {
+					return &DeploymentTargetError{Output: output, Err: err}
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			diags.Append(diagnose("Cannot upload runtime", err)...)
+			return diags
+		}
+	}
+
+	// Upload artifact.
+	{
+		tmpDir := osx.TempDir("courier-")
+		defer func() { _ = os.RemoveAll(tmpDir) }()
+
+		err := os.WriteFile( //nolint:gosec
+			fmt.Sprintf("%s/command", tmpDir),
+			[]byte(art.Command.ValueString()),
+			0o640,
+		)
+		if err != nil {
+			diags.Append(diag.NewErrorDiagnostic(
+				"Cannot prepare command",
+				fmt.Sprintf("Cannot write command: %v", err),
+			))
+
+			return diags
+		}
+
+		var (
+			ports    = make([][2]int, 0, len(art.Ports))
+			portsBuf bytes.Buffer
+		)
+		for i := range art.Ports {
+			if art.Ports[i].IsNull() ||
+				art.Ports[i].IsUnknown() {
+				continue
+			}
+			port := int(art.Ports[i].ValueInt64())
+			publish := port
+			if i < len(d.PublishPorts) {
+				publish = d.PublishPorts[i]
+			}
+			ports = append(ports, [2]int{publish, port})
+		}
+		// Keep the declared order, the first port is the one the application listens on.
+		for i := range ports {
+			// Publish the port to another one in form of publish:port.
+			if ports[i][0] != ports[i][1] {
+				_, _ = fmt.Fprintf(&portsBuf, "%d:%d\n", ports[i][0], ports[i][1])
+				continue
+			}
+			_, _ = fmt.Fprintf(&portsBuf, "%d\n", ports[i][1])
+		}
+		err = os.WriteFile( //nolint:gosec
+			fmt.Sprintf("%s/ports", tmpDir),
+			portsBuf.Bytes(),
+			0o640)
+		if err != nil {
+			diags.Append(diag.NewErrorDiagnostic(
+				"Cannot prepare ports",
+				fmt.Sprintf("Cannot prepare ports: %v", err),
+			))
+
+			return diags
+		}
+
+		var (
+			envs    = make([]string, 0, len(art.Envs))
 			envsBuf bytes.Buffer
 		)
 		for k, v := range art.Envs {
 			if v.IsNull() || v.IsUnknown() {
 				envs = append(envs, fmt.Sprintf("%s=", k))
 			} else {
-				envs = append(envs, fmt.Sprintf("%s=%s", k, v.String()))
+				envs = append(envs, fmt.Sprintf("%s=%s", k, v.ValueString()))
+			}
+		}
+		sort.Strings(envs)
+		for i := range envs {
+			_, _ = fmt.Fprintf(&envsBuf, "%s\n", envs[i])
+		}
+		err = os.WriteFile( //nolint:gosec
+			fmt.Sprintf("%s/envs", tmpDir),
+			envsBuf.Bytes(),
+			0o640)
+		if err != nil {
+			diags.Append(diag.NewErrorDiagnostic(
+				"Cannot prepare envs",
+				fmt.Sprintf("Cannot prepare envs: %v", err),
+			))
+
+			return diags
+		}
+
+		var (
+			volumes    = make([]string, 0, len(art.Volumes))
+			volumesBuf bytes.Buffer
+		)
+		for i := range art.Volumes {
+			if art.Volumes[i].IsNull() ||
+				art.Volumes[i].IsUnknown() {
+				continue
+			}
+			volumes = append(volumes, art.Volumes[i].ValueString())
+		}
+		sort.Strings(volumes)
+		for i := range volumes {
+			_, _ = fmt.Fprintf(&volumesBuf, "%s\n", volumes[i])
+		}
+		err = os.WriteFile( //nolint:gosec
+			fmt.Sprintf("%s/volumes", tmpDir),
+			volumesBuf.Bytes(),
+			0o640,
+		)
+		if err != nil {
+			diags.Append(diag.NewErrorDiagnostic(
+				"Cannot prepare volumes",
+				fmt.Sprintf("Cannot prepare volumes: %v", err),
+			))
+
+			return diags
+		}
+
+		// Keep the secret envs in memory,
+		// which are uploaded with the owner only permission.
+		var (
+			secretEnvs    = make([]string, 0, len(art.SecretEnvs))
+			secretEnvsBuf bytes.Buffer
+		)
+		for k, v := range art.SecretEnvs {
+			if v.IsNull() || v.IsUnknown() {
+				secretEnvs = append(secretEnvs, fmt.Sprintf("%s=", k))
+			} else {
+				secretEnvs = append(secretEnvs, fmt.Sprintf("%s=%s", k, v.ValueString()))
+			}
+		}
+		sort.Strings(secretEnvs)
+		for i := range secretEnvs {
+			_, _ = fmt.Fprintf(&secretEnvsBuf, "%s\n", secretEnvs[i])
+		}
+
+		// Index the files, which are staged in files.d with the owner only permission,
+		// and copied to the destination by the runtime.
+		var (
+			files    = make([][]byte, len(art.Files))
+			filesBuf bytes.Buffer
+		)
+		for i := range art.Files {
+			files[i], err = art.Files[i].Read()
+			if err != nil {
+				diags.Append(diag.NewErrorDiagnostic(
+					"Cannot prepare files",
+					fmt.Sprintf("Cannot read file %s: %v", art.Files[i].Path.ValueString(), err),
+				))
+
+				return diags
+			}
+
+			owner := art.Files[i].Owner.ValueString()
+			if owner == "" {
+				owner = "-"
+			}
+			_, _ = fmt.Fprintf(&filesBuf, "%d %s %s %s\n",
+				i, art.Files[i].Mode.ValueString(), owner, art.Files[i].Path.ValueString())
+		}
+		err = os.WriteFile( //nolint:gosec
+			fmt.Sprintf("%s/files", tmpDir),
+			filesBuf.Bytes(),
+			0o640)
+		if err == nil {
+			err = os.Mkdir(fmt.Sprintf("%s/files.d", tmpDir), 0o700)
+		}
+		if err != nil {
+			diags.Append(diag.NewErrorDiagnostic(
+				"Cannot prepare files",
+				fmt.Sprintf("Cannot prepare files: %v", err),
+			))
+
+			return diags
+		}
+
+		// Persist the reference and the runtime for importing.
+		for _, f := range [][2]string{
+			{"uri", art.Refer.URI.ValueString()},
+			{"digest", art.Digest.ValueString()},
+			{"runtime_class", d.RuntimeClass},
+			{"runtime_source", d.RuntimeSource},
+		} {
+			err = os.WriteFile( //nolint:gosec
+				fmt.Sprintf("%s/%s", tmpDir, f[0]),
+				[]byte(f[1]),
+				0o640)
+			if err != nil {
+				diags.Append(diag.NewErrorDiagnostic(
+					"Cannot prepare "+f[0],
+					fmt.Sprintf("Cannot prepare %s: %v", f[0], err),
+				))
+
+				return diags
+			}
+		}
+
+		// Fetch the artifact once per platform and push it to the targets,
+		// which saves the targets from accessing the artifact.
+		if art.FetchMode.ValueString() == "push" {
+			// Reuse the artifact pushed before if unchanged,
+			// e.g. only the files changed.
+			var m sync.Mutex
+
+			_ = d.each(ctx, "setup", func(ctx context.Context, t DeploymentTarget) error {
+				if digest := t.Pushed(ctx, d.ID, art.Digest.ValueString()); digest != "" {
+					m.Lock()
+					reused[t.Address] = digest
+					m.Unlock()
+				}
+
+				return nil
+			})
+
+			var fetching []DeploymentTarget
+
+			for _, t := range d.Targets {
+				if _, ok := reused[t.Address]; !ok {
+					fetching = append(fetching, t)
+				}
+			}
+
+			fetchDir := osx.TempDir("courier-")
+			defer func() { _ = os.RemoveAll(fetchDir) }()
+
+			pushed, err = d.fetch(ctx, art, fetchDir, fetching)
+			if err != nil {
+				diags.Append(diag.NewErrorDiagnostic(
+					"Cannot fetch artifact",
+					fmt.Sprintf("Cannot fetch artifact: %v", err),
+				))
+
+				return diags
+			}
+		}
+
+		err = d.each(ctx, "setup", func(ctx context.Context, t DeploymentTarget) (err error) {
+			err = t.UploadDirectory(
+				ctx,
+				os.DirFS(tmpDir),
+				t.ArtifactDir(d.ID),
+				target.WithTransmitMode(0o640),
+				uploadProgress(ctx, t, "artifact"))
+			if err != nil {
+				return err
+			}
+
+			err = t.UploadFile(
+				ctx,
+				bytes.NewReader(secretEnvsBuf.Bytes()),
+				t.ArtifactDir(d.ID)+"/secret_envs",
+				target.WithTransmitMode(0o600))
+			if err != nil {
+				return err
+			}
+
+			for i := range files {
+				err = t.UploadFile(
+					ctx,
+					bytes.NewReader(files[i]),
+					fmt.Sprintf("%s/files.d/%d", t.ArtifactDir(d.ID), i),
+					target.WithTransmitMode(0o600))
+				if err != nil {
+					return err
+				}
+			}
+
+			if _, ok := reused[t.Address]; ok {
+				return nil
+			}
+
+			fp, ok := pushed[d.platform(t)]
+			if !ok {
+				return nil
+			}
+
+			f, err := os.Open(fp.Path)
+			if err != nil {
+				return err
+			}
+
+			defer func() { _ = f.Close() }()
+
+			err = t.UploadFileResumable(
+				ctx,
+				f,
+				t.ArtifactDir(d.ID)+"/artifact",
+				uploadProgress(ctx, t, "pushed artifact"))
+			if err != nil {
+				return err
+			}
+
+			// Record the pushed artifact after uploading completely.
+			return t.UploadFile(
+				ctx,
+				strings.NewReader(art.Digest.ValueString()+" "+fp.Digest),
+				t.ArtifactDir(d.ID)+"/pushed")
+		})
+		if err != nil {
+			diags.Append(diagnose("Cannot upload artifact", err)...)
+			return diags
+		}
+	}
+
+	{
+		args := []string{
+			"setup",
+			d.ID,
+			art.Refer.URI.ValueString(),
+			art.Digest.ValueString(),
+		}
+
+		if au := art.Refer.Authn; au != nil {
+			args = append(
+				args,
+				au.Type.ValueString(),
+				au.User.ValueString(),
+				au.Secret.ValueString(),
+			)
+		}
+
+		diags.Append(d.executeEach(ctx, args[0], func(t DeploymentTarget) []string {
+			// Install from the pushed file, which is verified by its checksum.
+			if digest, ok := reused[t.Address]; ok {
+				return []string{"setup", d.ID, "file://" + t.ArtifactDir(d.ID) + "/artifact", digest}
+			}
+
+			if f, ok := pushed[d.platform(t)]; ok {
+				return []string{"setup", d.ID, "file://" + t.ArtifactDir(d.ID) + "/artifact", f.Digest}
+			}
+
+			return args
+		})...)
+	}
+
+	return diags
+}
+
+// uploadProgress returns an option to log the uploading progress of the given target at intervals,
+// which helps to find out the slow target.
+func uploadProgress(ctx context.Context, t DeploymentTarget, what string) target.TransmitOption {
+	return target.WithTransmitProgress(func(p target.TransmitProgress) {
+		fields := map[string]any{
+			"address": t.Address,
+			"stage":   "setup",
+			"sent":    p.Sent,
+			"rate":    fmt.Sprintf("%.1f KiB/s", p.Rate/1024),
+		}
+
+		if p.Total >= 0 {
+			fields["total"] = p.Total
+		}
+
+		if p.Total > 0 {
+			fields["percent"] = fmt.Sprintf("%.1f%%", float64(p.Sent)*100/float64(p.Total))
+		}
+
+		tflog.Info(ctx, "Uploading "+what+"...", fields)
+	})
+}
+
+// deploymentFetched is the artifact fetched by the provider.
+type deploymentFetched struct {
+	// Path is the local path of the fetched file.
+	Path string
+	// Digest is the checksum of the fetched file.
+	Digest string
+}
+
+// platform returns the platform of the artifact to fetch for the given target,
+// which is blank if the artifact is not a container image.
+func (d Deployment) platform(t DeploymentTarget) string {
+	opts := artifact.ReferOptions{URI: d.Artifact.Refer.URI.ValueString()}
+	if opts.Type() != artifact.ReferTypeContainerImage || t.OS == "" || t.Arch == "" {
+		return ""
+	}
+
+	return t.OS + "/" + t.Arch
+}
+
+// fetch fetches the given artifact once per platform of the given targets into the given directory.
+func (d Deployment) fetch(
+	ctx context.Context,
+	art ResourceDeploymentArtifact,
+	dir string,
+	targets []DeploymentTarget,
+) (map[string]deploymentFetched, error) {
+	fetched := map[string]deploymentFetched{}
+
+	for _, t := range targets {
+		pf := d.platform(t)
+		if _, ok := fetched[pf]; ok {
+			continue
+		}
+
+		tflog.Debug(ctx, "Fetching artifact...",
+			map[string]any{
+				"uri":      art.Refer.URI.ValueString(),
+				"platform": pf,
+			})
+
+		p := fmt.Sprintf("%s/%d", dir, len(fetched))
+
+		f, err := os.Create(p)
+		if err != nil {
+			return nil, err
+		}
+
+		h := sha256.New()
+
+		err = art.Fetch(ctx, io.MultiWriter(f, h), pf)
+		_ = f.Close()
+
+		if err != nil {
+			return nil, err
+		}
+
+		fetched[pf] = deploymentFetched{
+			Path:   p,
+			Digest: "sha256:" + hex.EncodeToString(h.Sum(nil)),
+		}
+	}
+
+	return fetched, nil
+}
+
+// each calls the given function on the targets at most parallelism at once,
+// cancels the others on the first error,
+// or records the failed targets and skips them in the following calls if continue on error,
+// which returns error only if all targets failed.
+//
+// The returned error joins the error of each failed target in the given stage,
+// see DeploymentTargetError.
+func (d Deployment) each(
+	ctx context.Context,
+	stage string,
+	fn func(ctx context.Context, t DeploymentTarget) error,
+) error {
+	if d.failures == nil {
+		d.failures = &deploymentFailures{}
+	}
+
+	var (
+		errs = make([]error, len(d.Targets))
+		g    errgroup.Group
+	)
+
+	if d.Parallelism > 0 {
+		g.SetLimit(d.Parallelism)
+	}
+
+	gctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for i := range d.Targets {
+		i, t := i, d.Targets[i]
+		if d.ContinueOnError && d.failures.has(t.Address) {
+			continue
+		}
+
+		g.Go(func() error {
+			err := fn(gctx, t)
+			if err == nil {
+				return nil
+			}
+
+			errs[i] = newDeploymentTargetError(t.Address, stage, err)
+			d.failures.add(t.Address, errs[i])
+
+			if !d.ContinueOnError {
+				cancel()
+			}
+
+			return nil
+		})
+	}
+
+	_ = g.Wait()
+
+	if d.ContinueOnError {
+		for i := range d.Targets {
+			if !d.failures.has(d.Targets[i].Address) {
+				return nil
 			}
 		}
-		sort.Strings(envs)
-		for i := range envs {
-			_, _ = fmt.Fprintf(&envsBuf, "%s\n", envs[i])
+	}
+
+	var rerr error
+
+	for i := range errs {
+		// Ignore the targets canceled by the others.
+		if errs[i] == nil || ctx.Err() == nil && errors.Is(errs[i], context.Canceled) {
+			continue
 		}
-		err = os.WriteFile( //nolint:gosec
-			fmt.Sprintf("%s/envs", tmpDir),
-			envsBuf.Bytes(),
-			0o666)
-		if err != nil {
-			diags.Append(diag.NewErrorDiagnostic(
-				"Cannot prepare envs",
-				fmt.Sprintf("Cannot prepare envs: %v", err),
-			))
 
-			return diags
+		rerr = multierr.Append(rerr, errs[i])
+	}
+
+	if rerr == nil && !d.ContinueOnError {
+		for i := range errs {
+			rerr = multierr.Append(rerr, errs[i])
+		}
+	}
+
+	return rerr
+}
+
+// Failures returns the warnings of the targets failed but continued,
+// nothing if all targets failed, which are reported as errors.
+func (d Deployment) Failures() diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if !d.ContinueOnError || d.failures == nil {
+		return diags
+	}
+
+	for _, t := range d.Targets {
+		err := d.failures.get(t.Address)
+		if err == nil {
+			continue
+		}
+
+		detail := err.Error()
+
+		var te *DeploymentTargetError
+		if errors.As(err, &te) {
+			detail = te.Detail()
+		}
+
+		diags.Append(diag.NewWarningDiagnostic(
+			"Failed Target",
+			detail+"\n\nThe target was skipped and will be applied again by the next applying.",
+		))
+	}
+
+	if len(diags) == len(d.Targets) {
+		return nil
+	}
+
+	return diags
+}
+
+// deploymentFailures records the failed targets by address.
+type deploymentFailures struct {
+	m    sync.Mutex
+	errs map[string]error
+}
+
+func (f *deploymentFailures) add(address string, err error) {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	if f.errs == nil {
+		f.errs = map[string]error{}
+	}
+
+	f.errs[address] = err
+}
+
+func (f *deploymentFailures) get(address string) error {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	return f.errs[address]
+}
+
+func (f *deploymentFailures) has(address string) bool {
+	return f.get(address) != nil
+}
+
+// DeploymentTargetError is the error of the given stage on the target,
+// which carries the output of the remote command if any.
+type DeploymentTargetError struct {
+	Address string
+	Stage   string
+	Output  []byte
+	Err     error
+}
+
+func newDeploymentTargetError(address, stage string, err error) *DeploymentTargetError {
+	var te *DeploymentTargetError
+	if !errors.As(err, &te) {
+		return &DeploymentTargetError{
+			Address: address,
+			Stage:   stage,
+			Err:     err,
+		}
+	}
+
+	e := *te
+	if e.Address == "" {
+		e.Address = address
+	}
+
+	if e.Stage == "" {
+		e.Stage = stage
+	}
+
+	return &e
+}
+
+func (e *DeploymentTargetError) Error() string {
+	return fmt.Sprintf("%s on %s: %v", e.Stage, e.Address, e.Err)
+}
+
+func (e *DeploymentTargetError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit code of the remote command,
+// or -1 if the command did not exit.
+func (e *DeploymentTargetError) ExitCode() int {
+	var ee interface{ ExitStatus() int }
+	if errors.As(e.Err, &ee) {
+		return ee.ExitStatus()
+	}
+
+	return -1
+}
+
+// Detail returns the description of the error,
+// including the exit code and the tail of the output.
+func (e *DeploymentTargetError) Detail() string {
+	var sb strings.Builder
+
+	_, _ = fmt.Fprintf(&sb, "Target %s failed at stage %s", e.Address, e.Stage)
+	if c := e.ExitCode(); c >= 0 {
+		_, _ = fmt.Fprintf(&sb, " with exit code %d", c)
+	}
+
+	_, _ = fmt.Fprintf(&sb, ": %v", e.Err)
+
+	if tail := outputTail(e.Output, 20); tail != "" {
+		sb.WriteString("\n\nOutput:\n")
+		sb.WriteString(tail)
+	}
+
+	return sb.String()
+}
+
+// outputTail returns the last lines of the given output,
+// which is at most 4KiB.
+func outputTail(output []byte, lines int) string {
+	const maxSize = 4 << 10
+
+	o := strings.TrimSpace(string(output))
+	if len(o) > maxSize {
+		o = "..." + o[len(o)-maxSize:]
+	}
+
+	ls := strings.Split(o, "\n")
+	if len(ls) > lines {
+		ls = append([]string{"..."}, ls[len(ls)-lines:]...)
+	}
+
+	return strings.Join(ls, "\n")
+}
+
+// diagnose returns an error diagnostic with the given summary for each target of the given error.
+func diagnose(summary string, err error) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	for _, e := range multierr.Errors(err) {
+		detail := e.Error()
+
+		var te *DeploymentTargetError
+		if errors.As(e, &te) {
+			detail = te.Detail()
+		}
+
+		diags.Append(diag.NewErrorDiagnostic(summary, detail))
+	}
+
+	return diags
+}
+
+// InSlot returns a copy of the deployment in the given slot with the given ID,
+// the green slot publishes the artifact ports to the blue/green ports.
+func (d Deployment) InSlot(
+	id, slot string,
+	bg *ResourceDeploymentStrategyBlueGreen,
+) Deployment {
+	d.ID = id
+	d.Slot = slot
+	d.PublishPorts = nil
+
+	if slot == "green" && bg != nil {
+		d.PublishPorts = make([]int, 0, len(bg.Ports))
+		for i := range bg.Ports {
+			d.PublishPorts = append(d.PublishPorts, int(bg.Ports[i].ValueInt64()))
+		}
+	}
+
+	return d
+}
+
+// PublishedPort returns the port published for the given artifact port,
+// or the given port if it is not published by the slot.
+func (d Deployment) PublishedPort(port int64) int64 {
+	for i := range d.Artifact.Ports {
+		if d.Artifact.Ports[i].ValueInt64() == port && i < len(d.PublishPorts) {
+			return int64(d.PublishPorts[i])
+		}
+	}
+
+	return port
+}
+
+// Wait waits for the deployment healthy on all targets,
+// or running if the given health check is nil,
+// the health check probes the port published by the slot.
+func (d Deployment) Wait(
+	ctx context.Context,
+	hc *ResourceDeploymentStrategyHealthCheck,
+) diag.Diagnostics {
+	if hc != nil && !hc.Port.IsNull() && !hc.Port.IsUnknown() {
+		h := *hc
+		h.Port = types.Int64Value(d.PublishedPort(hc.Port.ValueInt64()))
+		hc = &h
+	}
+
+	err := d.each(ctx, "wait", func(ctx context.Context, t DeploymentTarget) error {
+		if hc != nil {
+			return hc.Wait(ctx, t)
+		}
+
+		return wait.PollImmediateWithContext(ctx, 2*time.Second, 3*time.Minute,
+			func(ctx context.Context) (bool, error) {
+				output, err := t.ExecuteWithOutput(target.WithBecome(ctx), t.Command(), "state", d.ID)
+				if err != nil {
+					return false, &DeploymentTargetError{Output: output, Err: err}
+				}
+
+				return parseDeploymentTargetStatus(output).Status == "running", nil
+			})
+	})
+	if err != nil {
+		return diagnose("Unhealthy Deployment", err)
+	}
+
+	return nil
+}
+
+// Switch links the given current path to the deployment on all targets,
+// and then executes the given command to switch the traffic if not blank.
+//
+// The command can get the following environment variables:
+//   - COURIER_ARTIFACT, the ID of the deployment.
+//   - COURIER_SLOT, the slot of the deployment.
+//   - COURIER_PORTS, the published ports separated by space.
+func (d Deployment) Switch(
+	ctx context.Context,
+	current, command string,
+) diag.Diagnostics {
+	ports := make([]string, 0, len(d.Artifact.Ports))
+	for i := range d.Artifact.Ports {
+		port := d.Artifact.Ports[i].ValueInt64()
+		if i < len(d.PublishPorts) {
+			port = int64(d.PublishPorts[i])
 		}
+		ports = append(ports, strconv.FormatInt(port, 10))
+	}
 
+	err := d.each(ctx, "switch", func(ctx context.Context, t DeploymentTarget) error {
 		var (
-			volumes    = make([]string, 0, len(art.Volumes))
-			volumesBuf bytes.Buffer
+			link   = t.ArtifactDir(current)
+			target = t.ArtifactDir(d.ID)
+			cmd    string
+			args   []string
 		)
-		for i := range art.Volumes {
-			if art.Volumes[i].IsNull() ||
-				art.Volumes[i].IsUnknown() {
-				continue
+
+		if t.OS == "windows" {
+			script := fmt.Sprintf(`
+				$link = %s
+				if (Test-Path -Path ${link}) { (Get-Item -Path ${link}).Delete() }
+				New-Item -ItemType Junction -Path ${link} -Target %s | Out-Null
+				$env:COURIER_ARTIFACT = %s
+				$env:COURIER_SLOT = %s
+				$env:COURIER_PORTS = %s
+				%s`,
+				powershellQuote(strings.ReplaceAll(link, "/", "\\")),
+				powershellQuote(strings.ReplaceAll(target, "/", "\\")),
+				powershellQuote(d.ID), powershellQuote(d.Slot), powershellQuote(strings.Join(ports, " ")),
+				command)
+			cmd, args = powershellCommand(script)
+		} else {
+			script := shquot.POSIXShell([]string{"ln", "-sfn", target, link})
+			if command != "" {
+				script += "\n" + command
+			}
+			cmd, args = "env", []string{
+				"COURIER_ARTIFACT=" + d.ID,
+				"COURIER_SLOT=" + d.Slot,
+				"COURIER_PORTS=" + strings.Join(ports, " "),
+				"sh", "-c", script,
 			}
-			volumes = append(volumes, art.Volumes[i].ValueString())
 		}
-		sort.Strings(volumes)
-		for i := range volumes {
-			_, _ = fmt.Fprintf(&volumesBuf, "%s\n", volumes[i])
+
+		output, err := t.ExecuteWithOutput(ctx, cmd, args...)
+		if err != nil {
+			return &DeploymentTargetError{Output: output, Err: err}
 		}
-		err = os.WriteFile( //nolint:gosec
-			fmt.Sprintf("%s/volumes", tmpDir),
-			volumesBuf.Bytes(),
-			0o666,
-		)
+
+		return nil
+	})
+	if err != nil {
+		return diagnose("Cannot switch to "+d.ID, err)
+	}
+
+	return nil
+}
+
+// powershellCommand returns the command to execute the given script in PowerShell,
+// the script is encoded in UTF-16LE and Base64 to keep the quotes and the line feeds intact.
+func powershellCommand(script string) (string, []string) {
+	var (
+		runes = utf16.Encode([]rune(script))
+		bs    = make([]byte, 2*len(runes))
+	)
+	for i := range runes {
+		binary.LittleEndian.PutUint16(bs[2*i:], runes[i])
+	}
+
+	return "powershell", []string{
+		"-NoProfile", "-NonInteractive",
+		"-EncodedCommand", base64.StdEncoding.EncodeToString(bs),
+	}
+}
+
+// powershellQuote returns the given string as a PowerShell verbatim string,
+// which expands neither the variables nor the escape sequences.
+func powershellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// Unswitch removes the given current path on all targets.
+func (d Deployment) Unswitch(
+	ctx context.Context,
+	current string,
+) diag.Diagnostics {
+	err := d.each(ctx, "unswitch", func(ctx context.Context, t DeploymentTarget) error {
+		link := t.ArtifactDir(current)
+
+		cmd, args := "rm", []string{"-f", link}
+		if t.OS == "windows" {
+			cmd, args = powershellCommand(fmt.Sprintf(
+				`$link = %s; if (Test-Path -Path ${link}) { (Get-Item -Path ${link}).Delete() }`,
+				powershellQuote(strings.ReplaceAll(link, "/", "\\"))))
+		}
+
+		output, err := t.ExecuteWithOutput(ctx, cmd, args...)
 		if err != nil {
-			diags.Append(diag.NewErrorDiagnostic(
-				"Cannot prepare volumes",
-				fmt.Sprintf("Cannot prepare volumes: %v", err),
-			))
+			return &DeploymentTargetError{Output: output, Err: err}
+		}
 
-			return diags
+		return nil
+	})
+	if err != nil {
+		return diagnose("Cannot remove "+current, err)
+	}
+
+	return nil
+}
+
+// Wait probes the given target until healthy,
+// returns error if the retries are exhausted.
+func (r ResourceDeploymentStrategyHealthCheck) Wait(
+	ctx context.Context,
+	t DeploymentTarget,
+) error {
+	var (
+		interval = time.Duration(r.Interval.ValueInt64()) * time.Second
+		timeout  = time.Duration(r.Timeout.ValueInt64()) * time.Second
+		lastErr  error
+	)
+
+	err := wait.ExponentialBackoffWithContext(ctx,
+		wait.Backoff{
+			Duration: interval,
+			Factor:   1,
+			Steps:    int(r.Retries.ValueInt64()) + 1,
+		},
+		func() (bool, error) {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			lastErr = r.Probe(ctx, t)
+			if lastErr != nil {
+				tflog.Debug(ctx, "Health check failed, retrying...",
+					map[string]any{
+						"error": lastErr.Error(),
+					})
+
+				return false, nil
+			}
+
+			return true, nil
+		})
+	if errors.Is(err, wait.ErrWaitTimeout) && lastErr != nil {
+		return fmt.Errorf("health check failed: %w", lastErr)
+	}
+
+	return err
+}
+
+// Probe checks the health of the given target once.
+func (r ResourceDeploymentStrategyHealthCheck) Probe(
+	ctx context.Context,
+	t DeploymentTarget,
+) error {
+	switch r.Type.ValueString() {
+	case "tcp", "http":
+		if r.Port.ValueInt64() == 0 {
+			return errors.New("missing health check port")
 		}
+	}
 
-		g, ctx := errgroup.WithContext(ctx)
+	address := net.JoinHostPort("127.0.0.1", strconv.FormatInt(r.Port.ValueInt64(), 10))
 
-		for i := range tgts {
-			t := tgts[i]
+	switch r.Type.ValueString() {
+	case "tcp":
+		c, err := t.Dial(ctx, "tcp", address)
+		if err != nil {
+			return err
+		}
 
-			g.Go(func() (err error) {
-				return t.UploadDirectory(
-					ctx,
-					os.DirFS(tmpDir),
-					fmt.Sprintf("/var/local/courier/artifact/%s", d.ID))
-			})
+		return c.Close()
+	case "http":
+		cli := &http.Client{
+			Transport: &http.Transport{
+				DialContext:       t.Dial,
+				DisableKeepAlives: true,
+			},
 		}
 
-		if err := g.Wait(); err != nil {
-			diags.Append(diag.NewErrorDiagnostic(
-				"Cannot upload artifact",
-				fmt.Sprintf("Cannot upload artifact: %v", err),
-			))
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+			"http://"+address+r.Path.ValueString(), nil)
+		if err != nil {
+			return err
+		}
 
-			return diags
+		resp, err := cli.Do(req)
+		if err != nil {
+			return err
 		}
-	}
 
-	{
-		args := []string{
-			"setup",
-			d.ID,
-			art.Refer.URI.ValueString(),
-			art.Digest.ValueString(),
+		_ = resp.Body.Close()
+
+		if int64(resp.StatusCode) != r.Status.ValueInt64() {
+			return fmt.Errorf("unexpected status %d", resp.StatusCode)
 		}
 
-		if au := art.Refer.Authn; au != nil {
-			args = append(
-				args,
-				au.Type.ValueString(),
-				au.User.ValueString(),
-				au.Secret.ValueString(),
-			)
+		return nil
+	case "command":
+		cmd, args := "sh", []string{"-c", r.Command.ValueString()}
+		if t.OS == "windows" {
+			cmd, args = "powershell", []string{
+				"-NoProfile", "-NonInteractive", "-Command", r.Command.ValueString(),
+			}
+		}
+
+		output, err := t.ExecuteWithOutput(ctx, cmd, args...)
+		if err != nil {
+			return fmt.Errorf("%w: %s", err, string(output))
 		}
 
-		diags.Append(d.execute(ctx, args...)...)
+		return nil
 	}
 
-	return diags
+	return fmt.Errorf("unknown health check type %q", r.Type.ValueString())
 }
 
 func (d Deployment) Start(ctx context.Context) diag.Diagnostics {
@@ -506,42 +2820,72 @@ func (d Deployment) execute(ctx context.Context, args ...string) diag.Diagnostic
 		}
 	}
 
-	g, ctx := errgroup.WithContext(ctx)
-
-	for i := range d.Targets {
-		t := d.Targets[i]
+	return d.executeEach(ctx, args[0], func(DeploymentTarget) []string {
+		return args
+	})
+}
 
-		g.Go(func() error {
-			output, err := t.ExecuteWithOutput(
-				ctx,
-				t.Command(),
-				args...,
-			)
-			if err != nil {
-				tflog.Error(ctx, "cannot execute "+args[0]+": "+string(output))
+// executeEach executes the given stage on all targets with the arguments of each target.
+func (d Deployment) executeEach(
+	ctx context.Context,
+	stage string,
+	argsFn func(t DeploymentTarget) []string,
+) diag.Diagnostics {
+	err := d.each(ctx, stage, func(ctx context.Context, t DeploymentTarget) error {
+		// Log the output line by line as it arrives,
+		// and keep the tail for reporting.
+		var (
+			fields = map[string]any{
+				"address": t.Address,
+				"stage":   stage,
 			}
+			tail = make([]string, 0, 21)
+		)
 
-			return err
+		lw := iox.LineWriter(func(line string) {
+			tflog.Info(ctx, line, fields)
+
+			tail = append(tail, line)
+			if len(tail) > 20 {
+				tail = append(tail[:0], tail[1:]...)
+			}
 		})
-	}
 
-	if err := g.Wait(); err != nil {
-		return diag.Diagnostics{
-			diag.NewErrorDiagnostic(
-				"Cannot execute "+args[0],
-				fmt.Sprintf("Cannot execute %s: %v", args[0], err),
-			),
+		err := t.ExecuteWithStream(
+			target.WithBecome(ctx),
+			lw,
+			t.Command(),
+			argsFn(t)...,
+		)
+		_ = lw.Close()
+
+		if err != nil {
+			return &DeploymentTargetError{Output: []byte(strings.Join(tail, "\n")), Err: err}
 		}
+
+		return nil
+	})
+	if err != nil {
+		return diagnose("Cannot execute "+stage, err)
 	}
 
 	return nil
 }
 
+// Reflect returns the runtime source,
+// the source defaults to the runtime of the given provider configuration if not specified.
 func (r ResourceDeploymentRuntime) Reflect(
 	ctx context.Context,
+	cfg ProviderConfig,
 ) (runtime.Source, error) {
 	if r.Source.ValueString() == "" {
-		return runtime.BuiltinSource(), nil
+		if cfg.Runtime == nil {
+			return runtime.BuiltinSource(), nil
+		}
+
+		r.Source = cfg.Runtime.Source
+		r.Authn = cfg.Runtime.Authn
+		r.Insecure = cfg.Runtime.Insecure
 	}
 
 	opts := runtime.ExternalSourceOptions{
@@ -567,18 +2911,60 @@ func (r ResourceDeploymentStrategy) Equal(
 	}
 
 	if r.Rolling != nil && l.Rolling != nil {
-		return r.Rolling.Equal(*l.Rolling)
+		if !r.Rolling.Equal(*l.Rolling) {
+			return false
+		}
 	} else if r.Rolling != nil || l.Rolling != nil {
 		return false
 	}
 
+	if r.BlueGreen != nil && l.BlueGreen != nil {
+		if !r.BlueGreen.Equal(*l.BlueGreen) {
+			return false
+		}
+	} else if r.BlueGreen != nil || l.BlueGreen != nil {
+		return false
+	}
+
+	if r.Canary != nil && l.Canary != nil {
+		if *r.Canary != *l.Canary {
+			return false
+		}
+	} else if r.Canary != nil || l.Canary != nil {
+		return false
+	}
+
+	if r.HealthCheck != nil && l.HealthCheck != nil {
+		return *r.HealthCheck == *l.HealthCheck
+	} else if r.HealthCheck != nil || l.HealthCheck != nil {
+		return false
+	}
+
 	return true
 }
 
 func (r ResourceDeploymentStrategyRolling) Equal(
 	l ResourceDeploymentStrategyRolling,
 ) bool {
-	return r.MaxSurge.Equal(l.MaxSurge)
+	return r.MaxSurge.Equal(l.MaxSurge) &&
+		r.RollbackOnFailure.Equal(l.RollbackOnFailure)
+}
+
+func (r ResourceDeploymentStrategyBlueGreen) Equal(
+	l ResourceDeploymentStrategyBlueGreen,
+) bool {
+	if !r.SwitchCommand.Equal(l.SwitchCommand) ||
+		len(r.Ports) != len(l.Ports) {
+		return false
+	}
+
+	for i := range r.Ports {
+		if !r.Ports[i].Equal(l.Ports[i]) {
+			return false
+		}
+	}
+
+	return true
 }
 
 func (r *ResourceDeployment) Metadata(
@@ -621,8 +3007,9 @@ in the form of [schema://](ip|dns)[:port].`,
 									},
 								},
 								"authn": schema.SingleNestedAttribute{
-									Required:    true,
-									Description: `The authentication for accessing the host.`,
+									Optional: true,
+									Description: `The authentication for accessing the host, 
+defaults to the authn of the provider.`,
 									Attributes: map[string]schema.Attribute{
 										"type": schema.StringAttribute{
 											Optional: true,
@@ -652,9 +3039,8 @@ in the form of [schema://](ip|dns)[:port].`,
 											Default: stringdefault.StaticString(
 												"",
 											),
-											Description: `The secret to authenticate when accessing the target, 
-either password or private key.`,
-											Sensitive: true,
+											Description: hostAuthnSecretDescription,
+											Sensitive:   true,
 										},
 										"agent": schema.BoolAttribute{
 											Optional: true,
@@ -662,18 +3048,52 @@ either password or private key.`,
 											Default: booldefault.StaticBool(
 												false,
 											),
-											Description: `Specify to access the target with agent,
-either SSH agent if type is "ssh" or NTLM if type is "winrm".`,
+											Description: hostAuthnAgentDescription,
+										},
+										"passphrase": schema.StringAttribute{
+											Optional: true,
+											Computed: true,
+											Default: stringdefault.StaticString(
+												"",
+											),
+											Description: hostAuthnPassphraseDescription,
+											Sensitive:   true,
+										},
+										"certificate": schema.StringAttribute{
+											Optional: true,
+											Computed: true,
+											Default: stringdefault.StaticString(
+												"",
+											),
+											Description: hostAuthnCertificateDescription,
+										},
+										"password": schema.StringAttribute{
+											Optional: true,
+											Computed: true,
+											Default: stringdefault.StaticString(
+												"",
+											),
+											Description: hostAuthnPasswordDescription,
+											Sensitive:   true,
 										},
+										"become":   deploymentBecomeSchema(),
+										"kerberos": deploymentKerberosSchema(),
 									},
 								},
+								"host_key": deploymentHostKeySchema("target"),
+								"ca_cert": schema.StringAttribute{
+									Optional: true,
+									Description: `The PEM encoded CA certificates to verify the target, 
+only works if type is "winrm" and the address is in the form of https://.`,
+								},
 								"insecure": schema.BoolAttribute{
 									Optional: true,
 									Computed: true,
 									Default: booldefault.StaticBool(
 										false,
 									),
-									Description: `Specify to access the target with insecure mode.`,
+									Description: `Specify to access the target with insecure mode,
+which skips the host key or the certificate verification.`,
 								},
 								"proxies": schema.ListNestedAttribute{
 									Optional: true,
@@ -716,8 +3136,7 @@ either "ssh" or "proxy".`,
 														Default: stringdefault.StaticString(
 															"",
 														),
-														Description: `The user to authenticate 
-when accessing the proxy.`,
+														Description: proxyAuthnUserDescription,
 													},
 													"secret": schema.StringAttribute{
 														Optional: true,
@@ -725,19 +3144,46 @@ when accessing the proxy.`,
 														Default: stringdefault.StaticString(
 															"",
 														),
-														Description: `The secret to authenticate 
-when accessing the proxy, either password or private key.`,
-														Sensitive: true,
+														Description: proxyAuthnSecretDescription,
+														Sensitive:   true,
+													},
+													"passphrase": schema.StringAttribute{
+														Optional: true,
+														Computed: true,
+														Default: stringdefault.StaticString(
+															"",
+														),
+														Description: hostAuthnPassphraseDescription,
+														Sensitive:   true,
+													},
+													"certificate": schema.StringAttribute{
+														Optional: true,
+														Computed: true,
+														Default: stringdefault.StaticString(
+															"",
+														),
+														Description: proxyAuthnCertificateDescription,
+													},
+													"password": schema.StringAttribute{
+														Optional: true,
+														Computed: true,
+														Default: stringdefault.StaticString(
+															"",
+														),
+														Description: proxyAuthnPasswordDescription,
+														Sensitive:   true,
 													},
 												},
 											},
+											"host_key": deploymentHostKeySchema("proxy"),
 											"insecure": schema.BoolAttribute{
 												Optional: true,
 												Computed: true,
 												Default: booldefault.StaticBool(
 													false,
 												),
-												Description: `Specify to access the target with insecure mode.`,
+												Description: `Specify to access the proxy with insecure mode,
+which skips the host key verification.`,
 											},
 										},
 									},
@@ -752,6 +3198,39 @@ when accessing the proxy, either password or private key.`,
 							Required:    true,
 							Description: `The architecture of the target.`,
 						},
+						"install_dir": schema.StringAttribute{
+							Optional: true,
+							Computed: true,
+							Description: `The directory to install the runtime and the artifact on the target, 
+defaults to the install_dir of the provider, 
+or "/var/local/courier" on Linux, or "C:\ProgramData\courier" on Windows.`,
+						},
+						"status": schema.StringAttribute{
+							Computed: true,
+							Description: `Observes the status of the deployment on the target,
+either "running", "stopped", "missing", "unknown" or "failed", 
+the "failed" target is applied again by the next applying.`,
+						},
+						"digest": schema.StringAttribute{
+							Computed: true,
+							Description: `Observes the digest of the running artifact on the target,
+in form of algorithm:checksum.`,
+						},
+						"started_at": schema.StringAttribute{
+							Computed: true,
+							Description: `Observes the time when the artifact started on the target,
+in RFC3339 format.`,
+						},
+						"artifact_uri": schema.StringAttribute{
+							Computed:    true,
+							Description: `Observes the URI of the artifact deployed on the target.`,
+						},
+						"artifact_identity": schema.StringAttribute{
+							Computed: true,
+							Description: `Observes the identity of the artifact deployed on the target, 
+which is the checksum of the URI, the digest and the configuration, 
+e.g. the command, ports, envs, volumes and files.`,
+						},
 					},
 				},
 			},
@@ -772,7 +3251,7 @@ when accessing the proxy, either password or private key.`,
 						},
 						Optional: true,
 						Description: `The source to fetch the runtime, 
-only support a git repository at present.
+only support a git repository at present, defaults to the runtime source of the provider.
 
   - For example:
     - https://github.com/foo/bar, clone the HEAD commit of the default branch.
@@ -826,7 +3305,47 @@ only support a git repository at present.
 			},
 			"artifact": schema.SingleNestedAttribute{
 				PlanModifiers: []planmodifier.Object{
-					objectplanmodifier.RequiresReplace(),
+					objectplanmodifier.RequiresReplaceIf(
+						func(
+							ctx context.Context,
+							req planmodifier.ObjectRequest,
+							resp *objectplanmodifier.RequiresReplaceIfFuncResponse,
+						) {
+							// Ignore the changes which do not affect the deployment,
+							// e.g. the authentication missing from an imported state.
+							var (
+								plan, state ResourceDeploymentArtifact
+								digest      types.String
+							)
+							resp.Diagnostics.Append(req.Plan.GetAttribute(ctx,
+								path.Root("artifact"), &plan)...)
+							resp.Diagnostics.Append(req.State.GetAttribute(ctx,
+								path.Root("artifact"), &state)...)
+							resp.Diagnostics.Append(req.Config.GetAttribute(ctx,
+								path.Root("artifact").AtName("digest"), &digest)...)
+							if resp.Diagnostics.HasError() {
+								return
+							}
+
+							// The changed files restart the deployment only.
+							plan.CarryDigest(digest, state)
+							plan.Files, state.Files = nil, nil
+							if state.Equal(plan) {
+								return
+							}
+
+							var typ types.String
+							resp.Diagnostics.Append(req.Plan.GetAttribute(ctx,
+								path.Root("strategy").AtName("type"), &typ)...)
+							switch typ.ValueString() {
+							case "rolling", "blue_green", "canary":
+							default:
+								resp.RequiresReplace = true
+							}
+						},
+						"Requires replace if the artifact changed and the strategy is recreate.",
+						"Requires replace if the artifact changed and the strategy is recreate.",
+					),
 				},
 				Required:    true,
 				Description: `The artifact of the deployment.`,
@@ -907,6 +3426,91 @@ only support a git repository at present.
 						Description: `The environment variables of the artifact.`,
 						ElementType: types.StringType,
 					},
+					"secret_envs": schema.MapAttribute{
+						Optional:  true,
+						Computed:  true,
+						Sensitive: true,
+						Default: mapdefault.StaticValue(
+							basetypes.NewMapNull(types.StringType),
+						),
+						Description: `The secret environment variables of the artifact, 
+which are uploaded to the target in a file only readable by the service user, 
+and loaded by the service without appearing in the process arguments, 
+e.g. systemd credentials or docker env file, the value must be a single line.`,
+						ElementType: types.StringType,
+						Validators: []validator.Map{
+							mapvalidator.ValueStringsAre(
+								stringvalidator.RegexMatches(
+									regexp.MustCompile(`^[^\r\n]*$`),
+									"must be a single line",
+								),
+							),
+						},
+					},
+					"files": schema.ListNestedAttribute{
+						Optional: true,
+						Description: `The files delivered along with the artifact, e.g. configuration files, 
+which are uploaded to the target before setting up the artifact, 
+the changed files restart the deployment without downloading the artifact again.`,
+						NestedObject: schema.NestedAttributeObject{
+							Attributes: map[string]schema.Attribute{
+								"path": schema.StringAttribute{
+									Required: true,
+									Description: `The destination path relative to the artifact directory, 
+e.g. "tomcat/conf/context.xml" for the tomcat runtime.`,
+									Validators: []validator.String{
+										stringvalidator.RegexMatches(
+											regexp.MustCompile(artifactFilePathRegex),
+											"must be a relative path without spaces or dot segments",
+										),
+									},
+								},
+								"content": schema.StringAttribute{
+									Optional: true,
+									Description: `The inline content of the file, 
+e.g. rendered by the "templatefile" function.`,
+									Validators: []validator.String{
+										stringvalidator.ExactlyOneOf(
+											path.MatchRelative().AtParent().AtName("source"),
+										),
+									},
+								},
+								"source": schema.StringAttribute{
+									Optional:    true,
+									Description: `The local path of the file to upload.`,
+								},
+								"mode": schema.StringAttribute{
+									Optional:    true,
+									Computed:    true,
+									Default:     stringdefault.StaticString("0644"),
+									Description: `The permission of the file in octal, e.g. "0600".`,
+									Validators: []validator.String{
+										stringvalidator.RegexMatches(
+											regexp.MustCompile(`^0?[0-7]{3}$`),
+											"must be an octal permission",
+										),
+									},
+								},
+								"owner": schema.StringAttribute{
+									Optional:    true,
+									Description: `The owner of the file, in form of user[:group].`,
+									Validators: []validator.String{
+										stringvalidator.RegexMatches(
+											regexp.MustCompile(`^[\w.-]+(:[\w.-]+)?$`),
+											"must be in form of user[:group]",
+										),
+									},
+								},
+								"digest": schema.StringAttribute{
+									PlanModifiers: []planmodifier.String{
+										artifactFileDigester{},
+									},
+									Computed:    true,
+									Description: `The digest of the file content, in form of sha256:checksum.`,
+								},
+							},
+						},
+					},
 					"volumes": schema.ListAttribute{
 						Optional: true,
 						Computed: true,
@@ -917,14 +3521,62 @@ only support a git repository at present.
 						ElementType: types.StringType,
 					},
 					"digest": schema.StringAttribute{
-						Optional:    true,
-						Computed:    true,
-						Default:     stringdefault.StaticString(""),
-						Description: `The digest of the artifact, in form of algorithm:checksum.`,
+						PlanModifiers: []planmodifier.String{
+							artifactDigestTracker{},
+						},
+						Optional: true,
+						Computed: true,
+						Description: `The digest of the artifact, in form of algorithm:checksum, 
+resolved from the refer if not specified, the runtime verifies the artifact with it, 
+e.g. pulls the container image by digest.`,
+					},
+					"fetch_mode": schema.StringAttribute{
+						Optional: true,
+						Computed: true,
+						Default:  stringdefault.StaticString("pull"),
+						Description: `Specify how the targets get the artifact, either "pull" or "push", 
+"pull" lets each target download the artifact by itself, 
+"push" lets the provider download the artifact once and upload it to each target, 
+which works for the targets without egress, the container image is uploaded as a tarball, 
+the artifact pushed before is reused if unchanged, 
+the runtime dependencies are still installed by the targets if missing, e.g. docker or java.`,
+						Validators: []validator.String{
+							stringvalidator.OneOf(
+								"pull",
+								"push",
+							),
+						},
+					},
+					"track_digest": schema.BoolAttribute{
+						Optional: true,
+						Computed: true,
+						Default:  booldefault.StaticBool(false),
+						Description: `Specify to resolve the live digest of the artifact at planning 
+if the digest is not specified, 
+e.g. deploys again once the image tag or the download URL is republished.`,
 					},
 				},
 			},
 			"strategy": schema.SingleNestedAttribute{
+				PlanModifiers: []planmodifier.Object{
+					objectplanmodifier.RequiresReplaceIf(
+						func(
+							ctx context.Context,
+							req planmodifier.ObjectRequest,
+							resp *objectplanmodifier.RequiresReplaceIfFuncResponse,
+						) {
+							var stateType, planType types.String
+							resp.Diagnostics.Append(req.State.GetAttribute(ctx,
+								path.Root("strategy").AtName("type"), &stateType)...)
+							resp.Diagnostics.Append(req.Plan.GetAttribute(ctx,
+								path.Root("strategy").AtName("type"), &planType)...)
+							resp.RequiresReplace = stateType.ValueString() != planType.ValueString() &&
+								(stateType.ValueString() == "blue_green" || planType.ValueString() == "blue_green")
+						},
+						"Requires replace if switching from or to blue/green strategy.",
+						"Requires replace if switching from or to blue/green strategy.",
+					),
+				},
 				Optional:    true,
 				Description: `Specify the strategy of the deployment.`,
 				Attributes: map[string]schema.Attribute{
@@ -933,9 +3585,9 @@ only support a git repository at present.
 						Computed: true,
 						Default:  stringdefault.StaticString("recreate"),
 						Description: `The type of the deployment strategy,
-either "recreate" or "rolling".`,
+either "recreate", "rolling", "blue_green" or "canary".`,
 						Validators: []validator.String{
-							stringvalidator.OneOf("recreate", "rolling"),
+							stringvalidator.OneOf("recreate", "rolling", "blue_green", "canary"),
 						},
 					},
 					"rolling": schema.SingleNestedAttribute{
@@ -954,23 +3606,302 @@ either "recreate" or "rolling".`,
 									float64validator.AtMost(1),
 								},
 							},
+							"rollback_on_failure": schema.BoolAttribute{
+								Optional: true,
+								Computed: true,
+								Default:  booldefault.StaticBool(false),
+								Description: `Specify to put the targets already touched back on the previous artifact
+if any batch fails, including the failed ones, 
+the targets not deployed before are cleaned up instead, e.g. creating.`,
+							},
+						},
+					},
+					"health_check": schema.SingleNestedAttribute{
+						Optional: true,
+						Description: `The health check of the deployment on each target,
+gates the next batch of the rolling strategy, or the switching of the blue/green strategy.`,
+						Attributes: map[string]schema.Attribute{
+							"type": schema.StringAttribute{
+								Required:    true,
+								Description: `The type of the health check, either "http", "tcp" or "command".`,
+								Validators: []validator.String{
+									stringvalidator.OneOf("http", "tcp", "command"),
+								},
+							},
+							"port": schema.Int64Attribute{
+								Optional: true,
+								Description: `The port to check on the target, 
+only works if type is "http" or "tcp".`,
+								Validators: []validator.Int64{
+									int64validator.Between(1, 65535),
+								},
+							},
+							"path": schema.StringAttribute{
+								Optional:    true,
+								Computed:    true,
+								Default:     stringdefault.StaticString("/"),
+								Description: `The path to GET, only works if type is "http".`,
+							},
+							"status": schema.Int64Attribute{
+								Optional:    true,
+								Computed:    true,
+								Default:     int64default.StaticInt64(200),
+								Description: `The expected status code, only works if type is "http".`,
+							},
+							"command": schema.StringAttribute{
+								Optional: true,
+								Description: `The command to execute on the target, 
+only works if type is "command", exits with 0 if healthy.`,
+							},
+							"interval": schema.Int64Attribute{
+								Optional:    true,
+								Computed:    true,
+								Default:     int64default.StaticInt64(5),
+								Description: `The interval in seconds between checks.`,
+								Validators: []validator.Int64{
+									int64validator.AtLeast(1),
+								},
+							},
+							"timeout": schema.Int64Attribute{
+								Optional:    true,
+								Computed:    true,
+								Default:     int64default.StaticInt64(3),
+								Description: `The timeout in seconds of each check.`,
+								Validators: []validator.Int64{
+									int64validator.AtLeast(1),
+								},
+							},
+							"retries": schema.Int64Attribute{
+								Optional:    true,
+								Computed:    true,
+								Default:     int64default.StaticInt64(10),
+								Description: `The number of retries before treating the target as unhealthy.`,
+								Validators: []validator.Int64{
+									int64validator.AtLeast(0),
+								},
+							},
+						},
+					},
+					"canary": schema.SingleNestedAttribute{
+						Optional: true,
+						Description: `The canary strategy of the deployment,
+deploys the artifact to a part of the targets first and then pauses,
+the next apply continues the rollout once promoted or baked.`,
+						Attributes: map[string]schema.Attribute{
+							"count": schema.Int64Attribute{
+								Optional: true,
+								Description: `The number of targets to deploy first, 
+takes precedence over percent.`,
+								Validators: []validator.Int64{
+									int64validator.AtLeast(1),
+								},
+							},
+							"percent": schema.Float64Attribute{
+								Optional: true,
+								Computed: true,
+								Default: float64default.StaticFloat64(
+									0.1,
+								),
+								Description: `The percent of targets to deploy first.`,
+								Validators: []validator.Float64{
+									float64validator.AtLeast(0.01),
+									float64validator.AtMost(1),
+								},
+							},
+							"promote": schema.BoolAttribute{
+								Optional: true,
+								Computed: true,
+								Default:  booldefault.StaticBool(false),
+								Description: `Specify to continue the rollout of the paused canary, 
+set it back to false before the next rollout.`,
+							},
+							"bake_time": schema.Int64Attribute{
+								Optional: true,
+								Description: `The time in seconds to bake the paused canary, 
+the rollout continues after the bake time passes and the health check is green.`,
+								Validators: []validator.Int64{
+									int64validator.AtLeast(1),
+								},
+							},
+						},
+					},
+					"blue_green": schema.SingleNestedAttribute{
+						Optional: true,
+						Description: `The blue/green strategy of the deployment,
+deploys the artifact to the idle slot beside the active one,
+switches to the idle slot once it is running, and then cleans up the previous slot.`,
+						Attributes: map[string]schema.Attribute{
+							"ports": schema.ListAttribute{
+								Required: true,
+								Description: `The ports of the green slot, 
+which publish the artifact ports one by one, 
+the runtime listens on the published ports, 
+e.g. the openjdk runtime passes the one published for the first artifact port 
+as the "server.port" system property in the green slot.`,
+								ElementType: types.Int64Type,
+							},
+							"switch_command": schema.StringAttribute{
+								Optional: true,
+								Description: `The command to switch the traffic on the target after linking
+<install_dir>/artifact/<id>-current to the active slot, 
+e.g. rewriting the reverse-proxy configuration.
+
+  - The command can get the following environment variables:
+    - COURIER_ARTIFACT, the ID of the active slot.
+    - COURIER_SLOT, the active slot, either "blue" or "green".
+    - COURIER_PORTS, the ports of the active slot, separated by space.`,
+							},
 						},
 					},
 				},
 			},
+			"parallelism": schema.Int64Attribute{
+				Optional: true,
+				Description: `The maximum number of targets to operate at once, 
+defaults to the parallelism of the provider.`,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"continue_on_error": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				Default:  booldefault.StaticBool(false),
+				Description: `Specify to keep operating the other targets if any target fails, 
+the failed targets are skipped and reported as warnings, 
+the deployment fails only if all targets failed.`,
+			},
+			"canary_started_at": schema.StringAttribute{
+				Computed: true,
+				Description: `Observes the time when the canary paused, in RFC3339 format, 
+null if the rollout completed.`,
+			},
 			"timeouts": timeouts.Attributes(ctx, timeouts.Opts{
 				Create: true,
+				Read:   true,
 				Update: true,
 				Delete: true,
 			}),
 			"id": schema.StringAttribute{
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+					stringplanmodifier.UseStateForUnknown(),
+				},
+				Optional: true,
+				Computed: true,
+				Description: `The ID of the deployment, 
+names the artifact on the targets, e.g. <install_dir>/artifact/<id>, 
+defaults to a digest of the runtime class, the artifact reference and the target addresses, 
+which fails to create if in use on the targets, 
+e.g. replacing with create_before_destroy, specify another id in that case.`,
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(deploymentIDRegexp,
+						"must start with an alphanumeric character, "+
+							"and only contain alphanumeric characters, underscores, periods or hyphens"),
+				},
+			},
+			"slot": schema.StringAttribute{
+				Computed: true,
+				Description: `Observes the active slot of the blue/green deployment,
+either "blue" or "green".`,
+			},
+		},
+	}
+}
+
+// deploymentBecomeSchema returns the schema of the privilege escalation.
+func deploymentBecomeSchema() schema.SingleNestedAttribute {
+	return schema.SingleNestedAttribute{
+		Optional:    true,
+		Description: hostBecomeDescription,
+		Attributes: map[string]schema.Attribute{
+			"method": schema.StringAttribute{
+				Optional:    true,
+				Description: hostBecomeMethodDescription,
+				Validators:  hostBecomeMethodValidators(),
+			},
+			"user": schema.StringAttribute{
+				Optional:    true,
+				Description: hostBecomeUserDescription,
+				Validators:  hostBecomeUserValidators(),
+			},
+			"password": schema.StringAttribute{
+				Optional:    true,
+				Description: hostBecomePasswordDescription,
+				Sensitive:   true,
+			},
+		},
+	}
+}
+
+// deploymentKerberosSchema returns the schema of the Kerberos authentication.
+func deploymentKerberosSchema() schema.SingleNestedAttribute {
+	return schema.SingleNestedAttribute{
+		Optional:    true,
+		Description: hostKerberosDescription,
+		Attributes: map[string]schema.Attribute{
+			"realm": schema.StringAttribute{
+				Required:    true,
+				Description: hostKerberosRealmDescription,
+				Validators:  hostKerberosRealmValidators(),
+			},
+			"krb5_conf": schema.StringAttribute{
+				Optional:    true,
+				Description: hostKerberosKrb5ConfDescription,
+			},
+			"keytab": schema.StringAttribute{
+				Optional:    true,
+				Description: hostKerberosKeytabDescription,
+				Validators:  hostKerberosKeytabValidators(),
+			},
+			"ccache": schema.StringAttribute{
+				Optional:    true,
+				Description: hostKerberosCCacheDescription,
+			},
+			"spn": schema.StringAttribute{
+				Optional:    true,
+				Description: hostKerberosSPNDescription,
+			},
+		},
+	}
+}
+
+// deploymentHostKeySchema returns the schema of the host key verification of the given subject.
+func deploymentHostKeySchema(subject string) schema.SingleNestedAttribute {
+	return schema.SingleNestedAttribute{
+		Optional:    true,
+		Description: hostKeyDescription(subject),
+		Attributes: map[string]schema.Attribute{
+			"known_hosts": schema.StringAttribute{
+				Optional:    true,
+				Description: hostKeyKnownHostsDescription,
+			},
+			"fingerprints": schema.ListAttribute{
+				Optional:    true,
 				Computed:    true,
-				Description: `The ID of the deployment.`,
+				ElementType: types.StringType,
+				Description: hostKeyFingerprintsDescription,
+			},
+			"trust_on_first_use": schema.BoolAttribute{
+				Optional: true,
+				Description: `Specify to trust the unknown host key, 
+and record its fingerprint into the fingerprints on first use, 
+which pins the host key in the following runs.`,
 			},
 		},
 	}
 }
 
+func (r *ResourceDeployment) Configure(
+	ctx context.Context,
+	req resource.ConfigureRequest,
+	resp *resource.ConfigureResponse,
+) {
+	cfg, diags := getProviderConfig(req.ProviderData)
+	resp.Diagnostics.Append(diags...)
+	r.config = cfg
+}
+
 func (r *ResourceDeployment) Create(
 	ctx context.Context,
 	req resource.CreateRequest,
@@ -983,13 +3914,19 @@ func (r *ResourceDeployment) Create(
 		return
 	}
 
-	plan.ID = types.StringValue(strx.Sum(
-		plan.Artifact.Refer.URI.ValueString(),
-		strx.Hex(64)))
+	plan.config = r.config
+
+	defaulted := plan.ID.ValueString() == ""
+	if defaulted {
+		plan.ID = types.StringValue(plan.DefaultID())
+	}
+	plan.Slot = types.StringNull()
+	plan.CanaryStartedAt = types.StringNull()
+	plan.SetInstallDirs(r.config.InstallDir)
 
 	{
 		// Get timeout.
-		timeout, diags := plan.Timeouts.Create(ctx, 10*time.Minute)
+		timeout, diags := plan.Timeouts.Create(ctx, r.config.GetTimeout())
 		resp.Diagnostics.Append(diags...)
 		if resp.Diagnostics.HasError() {
 			return
@@ -998,11 +3935,27 @@ func (r *ResourceDeployment) Create(
 		ctx, cancel := context.WithTimeout(ctx, timeout)
 		defer cancel()
 
-		// Apply.
-		resp.Diagnostics.Append(plan.Apply(ctx, nil)...)
+		// Resolve.
+		resp.Diagnostics.Append(plan.ResolveDigest(ctx)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		// Claim.
+		resp.Diagnostics.Append(plan.Claim(ctx, defaulted)...)
 		if resp.Diagnostics.HasError() {
 			return
 		}
+
+		// Apply,
+		// records the deployment if partially applied.
+		resp.Diagnostics.Append(plan.Apply(ctx, nil)...)
+		if resp.Diagnostics.HasError() && !plan.Failed() {
+			return
+		}
+
+		// State.
+		resp.Diagnostics.Append(plan.State(ctx)...)
 	}
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
@@ -1013,6 +3966,156 @@ func (r *ResourceDeployment) Read(
 	req resource.ReadRequest,
 	resp *resource.ReadResponse,
 ) {
+	var state ResourceDeployment
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.config = r.config
+
+	{
+		// Get Timeout.
+		timeout, diags := state.Timeouts.Read(ctx, r.config.GetTimeout())
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		// Observe all targets,
+		// except the failed targets which are kept until applying again.
+		for i := range state.Targets {
+			if state.Targets[i].Status.ValueString() == "failed" {
+				continue
+			}
+
+			state.Targets[i].Status = types.StringUnknown()
+		}
+
+		// State.
+		resp.Diagnostics.Append(state.State(ctx)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func (r *ResourceDeployment) ModifyPlan(
+	ctx context.Context,
+	req resource.ModifyPlanRequest,
+	resp *resource.ModifyPlanResponse,
+) {
+	// Skip if destroying.
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var plan, state ResourceDeployment
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	modified := plan.SetInstallDirs(r.config.InstallDir)
+
+	// Skip if creating.
+	if req.State.Raw.IsNull() {
+		if modified {
+			resp.Diagnostics.Append(resp.Plan.Set(ctx, &plan)...)
+		}
+
+		return
+	}
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Keep the resolved digest.
+	var digest types.String
+
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx,
+		path.Root("artifact").AtName("digest"), &digest)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if plan.Artifact.CarryDigest(digest, state.Artifact) {
+		modified = true
+	}
+
+	stateTargetsIndex := make(map[string]ResourceDeploymentTarget, len(state.Targets))
+	for i := range state.Targets {
+		stateTargetsIndex[state.Targets[i].Host.Address.ValueString()] = state.Targets[i]
+	}
+
+	// Continue the paused canary if promoted or baking.
+	canary := plan.Strategy != nil && plan.Strategy.Type.ValueString() == "canary" &&
+		plan.Strategy.Canary != nil && !state.CanaryStartedAt.IsNull() &&
+		(plan.Strategy.Canary.Promote.ValueBool() || plan.Strategy.Canary.BakeTime.ValueInt64() > 0)
+
+	// Mark the drifted or moved targets as unknown to apply again.
+	for i := range plan.Targets {
+		st, ok := stateTargetsIndex[plan.Targets[i].Host.Address.ValueString()]
+		if !ok {
+			continue
+		}
+
+		// Keep the host key fingerprints trusted on first use.
+		if plan.Targets[i].Host.CarryHostKey(st.Host) {
+			modified = true
+		}
+
+		// Keep the artifact which the target is on.
+		if plan.Targets[i].ArtifactURI.IsUnknown() && state.Artifact.Equal(plan.Artifact) {
+			plan.Targets[i].ArtifactURI = st.ArtifactURI
+			plan.Targets[i].ArtifactIdentity = st.ArtifactIdentity
+			modified = true
+		}
+
+		if canary && st.Pending(state.Artifact) {
+			tflog.Debug(ctx, "Target pending, planning to continue the canary...",
+				map[string]any{
+					"address": st.Host.Address.ValueString(),
+				})
+
+			plan.Targets[i].ArtifactURI = types.StringUnknown()
+			plan.Targets[i].ArtifactIdentity = types.StringUnknown()
+			plan.Targets[i].Status = types.StringUnknown()
+			plan.Targets[i].Digest = types.StringUnknown()
+			plan.Targets[i].StartedAt = types.StringUnknown()
+			modified = true
+
+			continue
+		}
+
+		if !st.Drifted(state.Artifact) && !st.Moved(plan.Targets[i]) {
+			continue
+		}
+
+		tflog.Debug(ctx, "Target drifted or moved, planning to apply again...",
+			map[string]any{
+				"address": st.Host.Address.ValueString(),
+				"status":  st.Status.ValueString(),
+			})
+
+		plan.Targets[i].Status = types.StringUnknown()
+		plan.Targets[i].Digest = types.StringUnknown()
+		plan.Targets[i].StartedAt = types.StringUnknown()
+		modified = true
+	}
+
+	if modified {
+		resp.Diagnostics.Append(resp.Plan.Set(ctx, &plan)...)
+	}
 }
 
 func (r *ResourceDeployment) Update(
@@ -1028,27 +4131,74 @@ func (r *ResourceDeployment) Update(
 		return
 	}
 
+	plan.config = r.config
+	state.config = r.config
+
 	plan.ID = state.ID
+	plan.Slot = state.Slot
+	plan.CanaryStartedAt = state.CanaryStartedAt
+	plan.SetInstallDirs(r.config.InstallDir)
 
-	if plan.TargetsChanged(state) {
-		tflog.Debug(ctx, "Targets changed, applying again...")
+	// Apply all targets if the artifact changed,
+	// which only happens in rolling, blue/green or canary strategy.
+	changed := !state.Artifact.Equal(plan.Artifact)
 
-		// Diff.
-		planTargetsIndex := make(map[string]struct{}, len(plan.Targets))
-		for i := range plan.Targets {
-			planTargetsIndex[plan.Targets[i].Host.Address.ValueString()] = struct{}{}
+	// Diff.
+	stateTargetsIndex := make(map[string]ResourceDeploymentTarget, len(state.Targets))
+	for i := range state.Targets {
+		stateTargetsIndex[state.Targets[i].Host.Address.ValueString()] = state.Targets[i]
+	}
+
+	var pending bool
+
+	for i := range plan.Targets {
+		st, ok := stateTargetsIndex[plan.Targets[i].Host.Address.ValueString()]
+		if !ok || !plan.Targets[i].ArtifactURI.IsUnknown() {
+			continue
 		}
 
-		releaseTargets := make([]ResourceDeploymentTarget, 0, len(state.Targets))
-		for i := range state.Targets {
-			if _, ok := planTargetsIndex[state.Targets[i].Host.Address.ValueString()]; ok {
-				continue
-			}
-			releaseTargets = append(releaseTargets, state.Targets[i])
+		// Keep the artifact which the target is on.
+		plan.Targets[i].ArtifactURI = st.ArtifactURI
+		plan.Targets[i].ArtifactIdentity = st.ArtifactIdentity
+		if st.ArtifactURI.IsNull() {
+			plan.Targets[i].SetArtifact(state.Artifact)
+		}
+
+		pending = pending || plan.Targets[i].Pending(plan.Artifact)
+	}
+
+	// The canary strategy decides which targets to apply by itself.
+	if pending && plan.Strategy != nil && plan.Strategy.Type.ValueString() == "canary" {
+		changed = true
+	}
+
+	planTargetsIndex := make(map[string]ResourceDeploymentTarget, len(plan.Targets))
+	applyTargetsIndex := make([]int, 0, len(plan.Targets))
+	for i := range plan.Targets {
+		planTargetsIndex[plan.Targets[i].Host.Address.ValueString()] = plan.Targets[i]
+
+		// Apply the new targets, the drifted targets, the moved targets or the pending targets.
+		st, ok := stateTargetsIndex[plan.Targets[i].Host.Address.ValueString()]
+		if ok && !st.Drifted(state.Artifact) && !st.Moved(plan.Targets[i]) &&
+			!plan.Targets[i].Pending(plan.Artifact) && !changed {
+			continue
+		}
+		applyTargetsIndex = append(applyTargetsIndex, i)
+	}
+
+	releaseTargets := make([]ResourceDeploymentTarget, 0, len(state.Targets))
+	for i := range state.Targets {
+		// Release the removed targets or the moved targets from the previous install directory.
+		pt, ok := planTargetsIndex[state.Targets[i].Host.Address.ValueString()]
+		if ok && !state.Targets[i].Moved(pt) {
+			continue
 		}
+		releaseTargets = append(releaseTargets, state.Targets[i])
+	}
 
+	{
 		// Get Timeout.
-		timeout, diags := plan.Timeouts.Update(ctx, 10*time.Minute)
+		timeout, diags := plan.Timeouts.Update(ctx, r.config.GetTimeout())
 		resp.Diagnostics.Append(diags...)
 		if resp.Diagnostics.HasError() {
 			return
@@ -1057,18 +4207,59 @@ func (r *ResourceDeployment) Update(
 		ctx, cancel := context.WithTimeout(ctx, timeout)
 		defer cancel()
 
-		// Release.
-		state.Targets = releaseTargets
-		resp.Diagnostics.Append(state.Release(ctx)...)
+		// Resolve.
+		resp.Diagnostics.Append(plan.ResolveDigest(ctx)...)
 		if resp.Diagnostics.HasError() {
 			return
 		}
 
+		// Release.
+		if len(releaseTargets) != 0 {
+			tflog.Debug(ctx, "Targets removed, releasing...")
+
+			state.Targets = releaseTargets
+			resp.Diagnostics.Append(state.Release(ctx)...)
+			if resp.Diagnostics.HasError() {
+				return
+			}
+		}
+
 		// Apply.
-		resp.Diagnostics.Append(plan.Apply(ctx, &state.Artifact)...)
-		if resp.Diagnostics.HasError() {
-			return
+		if len(applyTargetsIndex) != 0 {
+			tflog.Debug(ctx, "Targets added, drifted or pending, applying again...")
+
+			partialPlan := plan
+			partialPlan.Targets = make([]ResourceDeploymentTarget, 0, len(applyTargetsIndex))
+			for _, i := range applyTargetsIndex {
+				partialPlan.Targets = append(partialPlan.Targets, plan.Targets[i])
+			}
+			// Record the deployment if partially applied,
+			// the failed targets are applied again by the next applying.
+			resp.Diagnostics.Append(partialPlan.Apply(ctx, &state.Artifact)...)
+			if resp.Diagnostics.HasError() && !partialPlan.Failed() {
+				return
+			}
+
+			for j, i := range applyTargetsIndex {
+				plan.Targets[i] = partialPlan.Targets[j]
+			}
+			plan.Slot = partialPlan.Slot
+			plan.CanaryStartedAt = partialPlan.CanaryStartedAt
+		}
+
+		// Nothing applied to the new targets.
+		for i := range plan.Targets {
+			if plan.Targets[i].ArtifactURI.IsUnknown() {
+				plan.Targets[i].ArtifactURI = types.StringNull()
+			}
+
+			if plan.Targets[i].ArtifactIdentity.IsUnknown() {
+				plan.Targets[i].ArtifactIdentity = types.StringNull()
+			}
 		}
+
+		// State.
+		resp.Diagnostics.Append(plan.State(ctx)...)
 	}
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
@@ -1086,8 +4277,10 @@ func (r *ResourceDeployment) Delete(
 		return
 	}
 
+	state.config = r.config
+
 	// Get Timeout.
-	timeout, diags := state.Timeouts.Delete(ctx, 10*time.Minute)
+	timeout, diags := state.Timeouts.Delete(ctx, r.config.GetTimeout())
 	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
 		return
@@ -1102,3 +4295,275 @@ func (r *ResourceDeployment) Delete(
 		return
 	}
 }
+
+// ImportState imports the deployment by the ID in form of "<id>,[<user>@]<address>[,...]",
+// accesses the targets with the authn of the provider or via SSH agent,
+// looks up the install directory configured in the provider,
+// and rebuilds the artifact and the runtime from the files persisted on the first target.
+func (r *ResourceDeployment) ImportState(
+	ctx context.Context,
+	req resource.ImportStateRequest,
+	resp *resource.ImportStateResponse,
+) {
+	id, addrs, _ := strings.Cut(req.ID, ",")
+	if !deploymentIDRegexp.MatchString(id) || addrs == "" {
+		resp.Diagnostics.Append(diag.NewErrorDiagnostic(
+			"Invalid Import ID",
+			fmt.Sprintf(`Expected import ID in form of "<id>,[<user>@]<address>[,...]", got %q`, req.ID),
+		))
+
+		return
+	}
+
+	state := ResourceDeployment{
+		ID:     types.StringValue(id),
+		config: r.config,
+	}
+
+	for _, addr := range strings.Split(addrs, ",") {
+		user := "root"
+		if i := strings.LastIndex(addr, "@"); i > 0 && !strings.Contains(addr[:i], "://") {
+			user, addr = addr[:i], addr[i+1:]
+		}
+
+		dt := DataSourceTarget{
+			Host: DataSourceTargetHost{
+				Address:  types.StringValue(addr),
+				Insecure: types.BoolValue(false),
+			},
+			config: r.config,
+		}
+
+		// Inherit the authn of the provider if configured.
+		if r.config.Authn == nil {
+			dt.Host.Authn = &DataSourceTargetHostAuthn{
+				Type:        types.StringValue("ssh"),
+				User:        types.StringValue(user),
+				Secret:      types.StringValue(""),
+				Agent:       types.BoolValue(true),
+				Passphrase:  types.StringValue(""),
+				Certificate: types.StringValue(""),
+				Password:    types.StringValue(""),
+			}
+		}
+
+		resp.Diagnostics.Append(dt.State(ctx)...)
+		if dt.OS.ValueString() == "" {
+			resp.Diagnostics.Append(diag.NewErrorDiagnostic(
+				"Inaccessible Target",
+				fmt.Sprintf("Cannot access the target %s", addr),
+			))
+
+			return
+		}
+
+		state.Targets = append(state.Targets, ResourceDeploymentTarget{
+			Host:        dt.Host,
+			OS:          dt.OS,
+			Arch:        dt.Arch,
+			InstallDir:  types.StringNull(),
+			Status:      types.StringNull(),
+			Digest:      types.StringNull(),
+			StartedAt:   types.StringNull(),
+			ArtifactURI: types.StringNull(),
+
+			ArtifactIdentity: types.StringNull(),
+		})
+	}
+
+	state.SetInstallDirs(r.config.InstallDir)
+
+	resp.Diagnostics.Append(state.importArtifact(ctx)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	for _, attr := range []struct {
+		name string
+		val  any
+	}{
+		{"id", state.ID},
+		{"targets", state.Targets},
+		{"artifact", state.Artifact},
+		{"runtime", state.Runtime},
+		{"slot", state.Slot},
+		{"canary_started_at", types.StringNull()},
+	} {
+		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root(attr.name), attr.val)...)
+	}
+}
+
+// importArtifact rebuilds the artifact and the runtime
+// from the files persisted on each target in the active blue/green slot if any,
+// takes the artifact of the first target,
+// and the targets on another artifact are pending.
+func (r *ResourceDeployment) importArtifact(
+	ctx context.Context,
+) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	for i := range r.Targets {
+		art, rt, slot, err := r.readArtifact(ctx, r.Targets[i])
+		if err != nil {
+			diags.Append(diag.NewAttributeErrorDiagnostic(
+				path.Root("targets").AtListIndex(i),
+				"Cannot import artifact",
+				fmt.Sprintf("Cannot import from %s: %v", r.Targets[i].Host.Address.ValueString(), err),
+			))
+
+			return diags
+		}
+
+		switch {
+		case i == 0:
+			r.Artifact, r.Runtime, r.Slot = art, rt, types.StringNull()
+			if slot != "" {
+				r.Slot = types.StringValue(slot)
+			}
+		case slot != r.Slot.ValueString():
+			diags.Append(diag.NewAttributeErrorDiagnostic(
+				path.Root("targets").AtListIndex(i),
+				"Cannot import artifact",
+				fmt.Sprintf("The active slot %q of %s is different from the slot %q of %s",
+					slot, r.Targets[i].Host.Address.ValueString(),
+					r.Slot.ValueString(), r.Targets[0].Host.Address.ValueString()),
+			))
+
+			return diags
+		}
+
+		r.Targets[i].SetArtifact(art)
+	}
+
+	return diags
+}
+
+// readArtifact reads the artifact, the runtime and the active blue/green slot
+// from the files persisted on the given target.
+func (r *ResourceDeployment) readArtifact(
+	ctx context.Context,
+	tg ResourceDeploymentTarget,
+) (art ResourceDeploymentArtifact, rt ResourceDeploymentRuntime, slot string, err error) {
+	h, err := tg.Host.Reflect(ctx, r.config)
+	if err != nil {
+		return art, rt, "", fmt.Errorf("cannot reflect from host: %w", err)
+	}
+
+	defer func() { _ = h.Close() }()
+
+	t := DeploymentTarget{
+		Host:       h,
+		OS:         tg.OS.ValueString(),
+		InstallDir: tg.GetInstallDir(),
+	}
+
+	// Find the active slot by the link of the current slot.
+	link := t.ArtifactDir(r.SlotID("current"))
+
+	cmd, args := "sh", []string{"-c", shquot.POSIXShell([]string{"readlink", link}) + ` 2>/dev/null || true`}
+	if t.OS == "windows" {
+		cmd, args = powershellCommand(fmt.Sprintf(
+			`(Get-Item -Path %s -ErrorAction SilentlyContinue).Target`,
+			powershellQuote(strings.ReplaceAll(link, "/", "\\"))))
+	}
+
+	output, err := t.ExecuteWithOutput(ctx, cmd, args...)
+	if err != nil {
+		return art, rt, "", fmt.Errorf("cannot read the current slot: %w: %s", err, string(output))
+	}
+
+	if target := strings.TrimRight(strings.TrimSpace(string(output)), `/\`); target != "" {
+		for _, s := range []string{"blue", "green"} {
+			if strings.HasSuffix(target, "-"+s) {
+				slot = s
+			}
+		}
+	}
+
+	var (
+		files = map[string]string{}
+		dir   = t.ArtifactDir(r.SlotID(slot))
+	)
+
+	for _, n := range []string{
+		"uri",
+		"digest",
+		"runtime_class",
+		"runtime_source",
+		"command",
+		"ports",
+		"envs",
+		"volumes",
+	} {
+		rd, err := h.DownloadFile(ctx, dir+"/"+n)
+		if err != nil {
+			return art, rt, "", fmt.Errorf("cannot download %s: %w", n, err)
+		}
+
+		bs, err := io.ReadAll(rd)
+		_ = rd.Close()
+
+		if err != nil {
+			return art, rt, "", fmt.Errorf("cannot read %s: %w", n, err)
+		}
+
+		files[n] = string(bs)
+	}
+
+	rt = ResourceDeploymentRuntime{
+		Class:    types.StringValue(files["runtime_class"]),
+		Source:   types.StringNull(),
+		Insecure: types.BoolNull(),
+	}
+	if files["runtime_source"] != "" {
+		rt.Source = types.StringValue(files["runtime_source"])
+	}
+
+	art = ResourceDeploymentArtifact{
+		Refer: DataSourceArtifactRefer{
+			URI:      types.StringValue(files["uri"]),
+			Insecure: types.BoolValue(false),
+		},
+		Command: types.StringValue(files["command"]),
+		Digest:  types.StringValue(files["digest"]),
+
+		TrackDigest: types.BoolValue(false),
+		FetchMode:   types.StringValue("pull"),
+	}
+
+	for _, l := range strings.Fields(files["ports"]) {
+		// Ports are in form of [publish:]port.
+		_, p, _ := strings.Cut(l, ":")
+		if p == "" {
+			p = l
+		}
+
+		port, err := strconv.ParseInt(p, 10, 64)
+		if err != nil {
+			return art, rt, "", fmt.Errorf("cannot parse port %q: %w", l, err)
+		}
+
+		art.Ports = append(art.Ports, types.Int64Value(port))
+	}
+
+	for _, l := range strings.Split(files["envs"], "\n") {
+		k, v, ok := strings.Cut(l, "=")
+		if !ok {
+			continue
+		}
+
+		if art.Envs == nil {
+			art.Envs = map[string]types.String{}
+		}
+
+		art.Envs[k] = types.StringValue(v)
+	}
+
+	for _, l := range strings.Split(files["volumes"], "\n") {
+		if l != "" {
+			art.Volumes = append(art.Volumes, types.StringValue(l))
+		}
+	}
+
+	return art, rt, slot, nil
+}
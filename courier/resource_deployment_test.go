@@ -2,12 +2,32 @@ package courier
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/binary"
 	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"unicode/utf16"
 
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-testing/config"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/multierr"
+
+	"github.com/seal-io/terraform-provider-courier/pkg/target"
 )
 
 func TestAccResourceDeployment_basic(t *testing.T) {
@@ -114,6 +134,15 @@ resource "courier_deployment" "test" {
 				},
 				Check: resource.ComposeAggregateTestCheckFunc(
 					resource.TestCheckResourceAttrSet(resourceName, "id"),
+					resource.TestCheckResourceAttr(
+						resourceName,
+						"targets.0.status",
+						"running",
+					),
+					resource.TestCheckResourceAttrSet(
+						resourceName,
+						"targets.0.started_at",
+					),
 				),
 			},
 		},
@@ -268,3 +297,714 @@ resource "courier_deployment" "test" {
 		},
 	})
 }
+
+func TestParseDeploymentTargetStatus(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    string
+		expected DeploymentTargetStatus
+	}{
+		{
+			name:  "missing",
+			input: "status=missing\n",
+			expected: DeploymentTargetStatus{
+				Status: "missing",
+			},
+		},
+		{
+			name: "running with logs",
+			input: "[INFO] [1016 10:00:00] observing\n" +
+				"status=running\n" +
+				"digest=sha256:89b33caa5bf4cfd235f060c396cb1a5acb2734a1366db325676f48c5f5ed92e5\n" +
+				"started_at=2023-10-16T10:00:00.123456789+08:00\n",
+			expected: DeploymentTargetStatus{
+				Status:    "running",
+				Digest:    "sha256:89b33caa5bf4cfd235f060c396cb1a5acb2734a1366db325676f48c5f5ed92e5",
+				StartedAt: "2023-10-16T02:00:00Z",
+			},
+		},
+		{
+			name:  "unrecognized",
+			input: "Unit tomcat-x.service could not be found.\n",
+			expected: DeploymentTargetStatus{
+				Status: "unknown",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual := parseDeploymentTargetStatus([]byte(c.input))
+			assert.Equal(t, c.expected, actual)
+		})
+	}
+}
+
+func TestDeployment_InSlot(t *testing.T) {
+	var (
+		d = Deployment{
+			ID: "x",
+			Artifact: ResourceDeploymentArtifact{
+				Ports: []types.Int64{types.Int64Value(80)},
+			},
+		}
+		bg = &ResourceDeploymentStrategyBlueGreen{
+			Ports: []types.Int64{types.Int64Value(8080)},
+		}
+	)
+
+	cases := []struct {
+		name     string
+		slot     string
+		expected []int
+	}{
+		{
+			name:     "blue",
+			slot:     "blue",
+			expected: nil,
+		},
+		{
+			name:     "green",
+			slot:     "green",
+			expected: []int{8080},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual := d.InSlot("x-"+c.slot, c.slot, bg)
+			assert.Equal(t, "x-"+c.slot, actual.ID)
+			assert.Equal(t, c.slot, actual.Slot)
+			assert.Equal(t, c.expected, actual.PublishPorts)
+		})
+	}
+}
+
+type dialHost struct {
+	target.Host
+
+	listening map[string]bool
+}
+
+func (h dialHost) Dial(_ context.Context, _, address string) (net.Conn, error) {
+	if !h.listening[address] {
+		return nil, errors.New("connection refused")
+	}
+
+	c, s := net.Pipe()
+	_ = s.Close()
+
+	return c, nil
+}
+
+func TestPowershellCommand(t *testing.T) {
+	script := fmt.Sprintf("$link = %s\nWrite-Output \"$link\"", powershellQuote(`C:\it's $HOME`))
+	assert.Equal(t, "$link = 'C:\\it''s $HOME'\nWrite-Output \"$link\"", script)
+
+	cmd, args := powershellCommand(script)
+	assert.Equal(t, "powershell", cmd)
+
+	if assert.Len(t, args, 4) {
+		assert.Equal(t, "-EncodedCommand", args[2])
+
+		bs, err := base64.StdEncoding.DecodeString(args[3])
+		if assert.NoError(t, err, "should not return error") {
+			runes := make([]uint16, len(bs)/2)
+			for i := range runes {
+				runes[i] = binary.LittleEndian.Uint16(bs[2*i:])
+			}
+
+			assert.Equal(t, script, string(utf16.Decode(runes)))
+		}
+	}
+}
+
+func TestDeployment_Wait(t *testing.T) {
+	var (
+		d = Deployment{
+			ID: "x",
+			Targets: []DeploymentTarget{
+				{
+					// Only the active blue slot is listening.
+					Host:    dialHost{listening: map[string]bool{"127.0.0.1:80": true}},
+					Address: "a",
+					OS:      "linux",
+				},
+			},
+			Artifact: ResourceDeploymentArtifact{
+				Ports: []types.Int64{types.Int64Value(80)},
+			},
+			failures: &deploymentFailures{},
+		}
+		bg = &ResourceDeploymentStrategyBlueGreen{
+			Ports: []types.Int64{types.Int64Value(8080)},
+		}
+		hc = &ResourceDeploymentStrategyHealthCheck{
+			Type:     types.StringValue("tcp"),
+			Port:     types.Int64Value(80),
+			Interval: types.Int64Value(0),
+			Timeout:  types.Int64Value(1),
+			Retries:  types.Int64Value(0),
+		}
+	)
+
+	ctx := context.TODO()
+
+	assert.False(t, d.InSlot("x-blue", "blue", bg).Wait(ctx, hc).HasError(),
+		"should probe the artifact port of the blue slot")
+	assert.True(t, d.InSlot("x-green", "green", bg).Wait(ctx, hc).HasError(),
+		"should probe the published port of the green slot")
+	assert.Equal(t, int64(80), hc.Port.ValueInt64(), "should not change the given health check")
+}
+
+func TestResourceDeployment_DefaultID(t *testing.T) {
+	newDeployment := func(addrs ...string) *ResourceDeployment {
+		r := &ResourceDeployment{
+			Runtime: ResourceDeploymentRuntime{
+				Class: types.StringValue("docker"),
+			},
+			Artifact: ResourceDeploymentArtifact{
+				Refer: DataSourceArtifactRefer{
+					URI: types.StringValue("nginx:latest"),
+				},
+			},
+		}
+
+		for i := range addrs {
+			r.Targets = append(r.Targets, ResourceDeploymentTarget{
+				Host: DataSourceTargetHost{
+					Address: types.StringValue(addrs[i]),
+				},
+			})
+		}
+
+		return r
+	}
+
+	a := newDeployment("10.0.0.1", "10.0.0.2").DefaultID()
+	assert.Equal(t, a, newDeployment("10.0.0.2", "10.0.0.1").DefaultID(),
+		"should be stable regardless of the target order")
+	assert.NotEqual(t, a, newDeployment("10.0.0.1").DefaultID(),
+		"should differ with different targets")
+	assert.Regexp(t, deploymentIDRegexp, a)
+}
+
+func TestIDInUse(t *testing.T) {
+	testCases := []struct {
+		name     string
+		inUse    []string
+		expected bool
+	}{
+		{
+			name:     "not in use",
+			inUse:    []string{"other"},
+			expected: false,
+		},
+		{
+			name:     "in use",
+			inUse:    []string{"x"},
+			expected: true,
+		},
+		{
+			name:     "slot in use",
+			inUse:    []string{"x-green", "x-current"},
+			expected: true,
+		},
+		{
+			name:     "another id in use",
+			inUse:    []string{"x-2", "x-2-blue"},
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			inUse := map[string]bool{}
+			for i := range tc.inUse {
+				inUse[tc.inUse[i]] = true
+			}
+
+			actual := idInUse("x", inUse)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestResourceDeploymentArtifact_CarryDigest(t *testing.T) {
+	newArtifact := func(uri string, digest types.String) ResourceDeploymentArtifact {
+		return ResourceDeploymentArtifact{
+			Refer:  DataSourceArtifactRefer{URI: types.StringValue(uri)},
+			Digest: digest,
+		}
+	}
+
+	state := newArtifact("nginx:latest", types.StringValue("sha256:a"))
+
+	// Keep the resolved digest of the same uri.
+	plan := newArtifact("nginx:latest", types.StringUnknown())
+	assert.True(t, plan.CarryDigest(types.StringNull(), state))
+	assert.Equal(t, types.StringValue("sha256:a"), plan.Digest)
+
+	// Resolve again if the uri changed.
+	plan = newArtifact("nginx:stable", types.StringUnknown())
+	assert.False(t, plan.CarryDigest(types.StringNull(), state))
+	assert.True(t, plan.Digest.IsUnknown())
+
+	// Respect the configured digest.
+	plan = newArtifact("nginx:latest", types.StringUnknown())
+	assert.False(t, plan.CarryDigest(types.StringUnknown(), state))
+	assert.True(t, plan.Digest.IsUnknown())
+}
+
+func TestResourceDeploymentArtifact_EqualFiles(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "application.yml")
+	if err := os.WriteFile(source, []byte("hello world"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	newArtifact := func(f ResourceDeploymentArtifactFile) ResourceDeploymentArtifact {
+		f.Path = types.StringValue("application.yml")
+		f.Mode = types.StringValue("0644")
+		f.Owner = types.StringNull()
+
+		digest, err := f.Sum()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		f.Digest = types.StringValue(digest)
+
+		return ResourceDeploymentArtifact{
+			Refer: DataSourceArtifactRefer{URI: types.StringValue("nginx:latest")},
+			Files: []ResourceDeploymentArtifactFile{f},
+		}
+	}
+
+	inline := newArtifact(ResourceDeploymentArtifactFile{
+		Content: types.StringValue("hello world"),
+		Source:  types.StringNull(),
+	})
+	sourced := newArtifact(ResourceDeploymentArtifactFile{
+		Content: types.StringNull(),
+		Source:  types.StringValue(source),
+	})
+	assert.Equal(t,
+		"sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9",
+		inline.Files[0].Digest.ValueString())
+	assert.True(t, inline.Equal(sourced), "should be equal with the same content")
+
+	// Restart only if the content changed.
+	changed := newArtifact(ResourceDeploymentArtifactFile{
+		Content: types.StringValue("hello"),
+		Source:  types.StringNull(),
+	})
+	assert.False(t, inline.Equal(changed), "should not be equal with different content")
+
+	changed.Files, inline.Files = nil, nil
+	assert.True(t, inline.Equal(changed), "should be equal without files")
+}
+
+func TestResourceDeploymentTarget_Pending(t *testing.T) {
+	art := ResourceDeploymentArtifact{
+		Refer:  DataSourceArtifactRefer{URI: types.StringValue("nginx:latest")},
+		Digest: types.StringValue("sha256:a"),
+		Envs:   map[string]types.String{"A": types.StringValue("1")},
+	}
+
+	var target ResourceDeploymentTarget
+
+	target.SetArtifact(art)
+	assert.False(t, target.Pending(art), "should not be pending on the same artifact")
+
+	// The digest moved with the same uri.
+	moved := art
+	moved.Digest = types.StringValue("sha256:b")
+	assert.True(t, target.Pending(moved), "should be pending if the digest moved")
+
+	// The envs changed with the same uri.
+	changed := art
+	changed.Envs = map[string]types.String{"A": types.StringValue("2")}
+	assert.True(t, target.Pending(changed), "should be pending if the envs changed")
+	assert.Equal(t, art.Equal(changed), art.Identity() == changed.Identity())
+
+	// Compare the uri only if the identity was not observed.
+	target.ArtifactIdentity = types.StringNull()
+	assert.False(t, target.Pending(moved), "should compare the uri without the identity")
+
+	// The new target is not on any artifact.
+	assert.False(t, ResourceDeploymentTarget{}.Pending(art), "should not be pending without artifact")
+}
+
+func TestArtifactFilePathRegex(t *testing.T) {
+	r := regexp.MustCompile(artifactFilePathRegex)
+
+	for _, p := range []string{"application.yml", "tomcat/conf/context.xml", ".env", "conf/..d/x"} {
+		assert.True(t, r.MatchString(p), "should match %q", p)
+	}
+
+	for _, p := range []string{"", ".", "..", "../x", "a/../b", "a/./b", "/etc/x", "a//b", "a b"} {
+		assert.False(t, r.MatchString(p), "should not match %q", p)
+	}
+}
+
+func TestResourceDeploymentArtifact_Resolve(t *testing.T) {
+	var (
+		content   = "v1"
+		downloads int
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"`+content+`"`)
+
+		if r.Method == http.MethodGet {
+			downloads++
+		}
+
+		_, _ = io.WriteString(w, content)
+	}))
+	defer srv.Close()
+
+	art := ResourceDeploymentArtifact{
+		Refer: DataSourceArtifactRefer{URI: types.StringValue(srv.URL + "/app-latest.war")},
+	}
+
+	ctx := context.TODO()
+
+	d1, err := art.Resolve(ctx)
+	if !assert.NoError(t, err, "should not return error") {
+		return
+	}
+
+	// Republish to the same uri.
+	content = "v2"
+
+	d2, err := art.Resolve(ctx)
+	if !assert.NoError(t, err, "should not return error") {
+		return
+	}
+
+	assert.NotEqual(t, d1, d2)
+	assert.Equal(t, "sha256:fb04dcb6970e4c3d1873de51fd5a50d7bb46b3383113602665c350ec40b5f990", d2)
+
+	// Reuse the digest if the etag is unchanged.
+	d3, err := art.Resolve(ctx)
+	if !assert.NoError(t, err, "should not return error") {
+		return
+	}
+
+	assert.Equal(t, d2, d3)
+	assert.Equal(t, 2, downloads, "should not download again")
+}
+
+func TestDeployment_fetch(t *testing.T) {
+	var requested int
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requested++
+		_, _ = io.WriteString(w, "v2")
+	}))
+	defer srv.Close()
+
+	art := ResourceDeploymentArtifact{
+		Refer:  DataSourceArtifactRefer{URI: types.StringValue(srv.URL + "/app.war")},
+		Digest: types.StringValue("sha256:fb04dcb6970e4c3d1873de51fd5a50d7bb46b3383113602665c350ec40b5f990"),
+	}
+
+	d := Deployment{
+		Targets: []DeploymentTarget{
+			{Address: "a", OS: "linux", Arch: "amd64"},
+			{Address: "b", OS: "linux", Arch: "arm64"},
+		},
+		Artifact: art,
+	}
+
+	ctx := context.TODO()
+
+	// Fetch once for all platforms.
+	fetched, err := d.fetch(ctx, art, t.TempDir(), d.Targets)
+	if assert.NoError(t, err, "should not return error") {
+		assert.Equal(t, 1, requested)
+		assert.Equal(t, art.Digest.ValueString(), fetched[""].Digest)
+	}
+
+	// Fetch nothing if all targets reuse the pushed artifact.
+	fetched, err = d.fetch(ctx, art, t.TempDir(), nil)
+	if assert.NoError(t, err, "should not return error") {
+		assert.Equal(t, 1, requested)
+		assert.Empty(t, fetched)
+	}
+
+	// Fail if the digest mismatches.
+	art.Digest = types.StringValue("sha256:3bfc269594ef649228e9a74bab00f042efc91d5acc6fbee31a382e80d42388fe")
+	_, err = d.fetch(ctx, art, t.TempDir(), d.Targets)
+	assert.Error(t, err)
+}
+
+type fileHost struct {
+	target.Host
+
+	files map[string]string
+}
+
+func (h fileHost) DownloadFile(_ context.Context, from string) (io.ReadCloser, error) {
+	s, ok := h.files[from]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+
+	return io.NopCloser(strings.NewReader(s)), nil
+}
+
+func TestDeploymentTarget_Pushed(t *testing.T) {
+	const pushed = "/var/local/courier/artifact/x/pushed"
+
+	cases := []struct {
+		name     string
+		files    map[string]string
+		expected string
+	}{
+		{
+			name:     "not pushed",
+			expected: "",
+		},
+		{
+			name:     "pushed",
+			files:    map[string]string{pushed: "sha256:a sha256:b\n"},
+			expected: "sha256:b",
+		},
+		{
+			name:     "pushed another artifact",
+			files:    map[string]string{pushed: "sha256:c sha256:b"},
+			expected: "",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dt := DeploymentTarget{
+				Host:       fileHost{files: c.files},
+				InstallDir: "/var/local/courier",
+			}
+
+			assert.Equal(t, c.expected, dt.Pushed(context.TODO(), "x", "sha256:a"))
+		})
+	}
+}
+
+func TestDeploymentTarget_Command(t *testing.T) {
+	cases := []struct {
+		name     string
+		given    ResourceDeploymentTarget
+		expected string
+	}{
+		{
+			name: "linux default",
+			given: ResourceDeploymentTarget{
+				OS: types.StringValue("linux"),
+			},
+			expected: "/var/local/courier/runtime/docker/linux/service.sh",
+		},
+		{
+			name: "windows default",
+			given: ResourceDeploymentTarget{
+				OS: types.StringValue("windows"),
+			},
+			expected: `C:\ProgramData\courier/runtime/docker/windows/service.ps1`,
+		},
+		{
+			name: "linux customized",
+			given: ResourceDeploymentTarget{
+				OS:         types.StringValue("linux"),
+				InstallDir: types.StringValue("/opt/courier/"),
+			},
+			expected: "/opt/courier/runtime/docker/linux/service.sh",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dt := DeploymentTarget{
+				RuntimeClass: "docker",
+				OS:           c.given.OS.ValueString(),
+				InstallDir:   c.given.GetInstallDir(),
+			}
+			assert.Equal(t, c.expected, dt.Command())
+		})
+	}
+}
+
+func TestDeployment_each(t *testing.T) {
+	newDeployment := func(continueOnError bool) Deployment {
+		return Deployment{
+			Targets: []DeploymentTarget{
+				{Address: "a"},
+				{Address: "b"},
+				{Address: "c"},
+			},
+			Parallelism:     1,
+			ContinueOnError: continueOnError,
+			failures:        &deploymentFailures{},
+		}
+	}
+
+	failOn := func(addrs ...string) func(context.Context, DeploymentTarget) error {
+		return func(_ context.Context, t DeploymentTarget) error {
+			for i := range addrs {
+				if t.Address == addrs[i] {
+					return errors.New("failed")
+				}
+			}
+
+			return nil
+		}
+	}
+
+	ctx := context.TODO()
+
+	// Stop on the first error.
+	d := newDeployment(false)
+	err := d.each(ctx, "setup", failOn("b"))
+	if assert.Error(t, err) {
+		assert.Equal(t, "setup on b: failed", err.Error())
+	}
+	assert.Empty(t, d.Failures())
+
+	// Continue on error.
+	d = newDeployment(true)
+	assert.NoError(t, d.each(ctx, "setup", failOn("b")))
+	assert.Len(t, d.Failures(), 1)
+
+	var called []string
+
+	assert.NoError(t, d.each(ctx, "setup", func(_ context.Context, t DeploymentTarget) error {
+		called = append(called, t.Address)
+		return nil
+	}))
+	assert.Equal(t, []string{"a", "c"}, called, "should skip the failed target")
+
+	// Fail if all targets failed.
+	assert.Error(t, d.each(ctx, "setup", failOn("a", "c")))
+}
+
+func TestDeploymentTargetError(t *testing.T) {
+	output := make([]string, 0, 30)
+	for i := 0; i < 30; i++ {
+		output = append(output, "line "+strconv.Itoa(i))
+	}
+
+	err := newDeploymentTargetError("10.0.0.1", "start", &DeploymentTargetError{
+		Output: []byte(strings.Join(output, "\n") + "\n"),
+		Err:    fmt.Errorf("wrapped: %w", &target.ExitError{Code: 2}),
+	})
+
+	assert.Equal(t, 2, err.ExitCode())
+	assert.Equal(t, "Target 10.0.0.1 failed at stage start with exit code 2: wrapped: exit status 2\n\n"+
+		"Output:\n...\n"+strings.Join(output[10:], "\n"), err.Detail())
+
+	err = newDeploymentTargetError("10.0.0.1", "setup", errors.New("broken"))
+	assert.Equal(t, -1, err.ExitCode())
+	assert.Equal(t, "Target 10.0.0.1 failed at stage setup: broken", err.Detail())
+
+	diags := diagnose("Cannot execute start", multierr.Combine(
+		newDeploymentTargetError("a", "start", errors.New("broken")),
+		newDeploymentTargetError("b", "start", errors.New("broken")),
+	))
+	assert.Len(t, diags, 2)
+}
+
+type streamHost struct {
+	target.Host
+
+	output string
+	err    error
+}
+
+func (h streamHost) ExecuteWithStream(_ context.Context, out io.Writer, _ string, _ ...string) error {
+	_, _ = io.WriteString(out, h.output)
+	return h.err
+}
+
+type recordHost struct {
+	target.Host
+
+	address  string
+	m        *sync.Mutex
+	executed *[]string
+}
+
+func (h recordHost) ExecuteWithStream(_ context.Context, _ io.Writer, _ string, args ...string) error {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	*h.executed = append(*h.executed, h.address+" "+args[0])
+
+	return nil
+}
+
+func TestResourceDeployment_rollback(t *testing.T) {
+	var (
+		m        sync.Mutex
+		executed []string
+	)
+
+	r := &ResourceDeployment{}
+	d := Deployment{
+		ID:              "app",
+		ContinueOnError: true,
+		failures:        &deploymentFailures{},
+	}
+
+	for _, addr := range []string{"a", "b"} {
+		r.Targets = append(r.Targets, ResourceDeploymentTarget{
+			Host: DataSourceTargetHost{Address: types.StringValue(addr)},
+		})
+		d.Targets = append(d.Targets, DeploymentTarget{
+			Host:    recordHost{address: addr, m: &m, executed: &executed},
+			Address: addr,
+			OS:      "linux",
+		})
+	}
+
+	// Target b failed and is skipped by the applying.
+	d.failures.add("b", errors.New("broken"))
+
+	// Clean up all touched targets if creating.
+	diags := r.rollback(context.TODO(), d, d.Targets, nil, nil)
+	if assert.True(t, diags.HasError()) {
+		assert.Equal(t, "Cleaned up", diags[len(diags)-1].Summary())
+	}
+
+	sort.Strings(executed)
+	assert.Equal(t, []string{"a cleanup", "b cleanup"}, executed)
+	assert.Equal(t, "missing", r.Targets[0].Status.ValueString())
+	assert.Equal(t, "missing", r.Targets[1].Status.ValueString())
+}
+
+func TestDeployment_execute(t *testing.T) {
+	d := Deployment{
+		ID: "app",
+		Targets: []DeploymentTarget{
+			{
+				Host:    streamHost{output: "pulling\r\ndone\n"},
+				Address: "a",
+				OS:      "linux",
+			},
+			{
+				Host:    streamHost{output: "pulling\nno space left", err: &target.ExitError{Code: 1}},
+				Address: "b",
+				OS:      "linux",
+			},
+		},
+		ContinueOnError: true,
+		failures:        &deploymentFailures{},
+	}
+
+	assert.False(t, d.Start(context.TODO()).HasError())
+
+	diags := d.Failures()
+	if assert.Len(t, diags, 1) {
+		assert.Equal(t, "Target b failed at stage start with exit code 1: exit status 1\n\n"+
+			"Output:\npulling\nno space left\n\n"+
+			"The target was skipped and will be applied again by the next applying.", diags[0].Detail())
+	}
+}
@@ -0,0 +1,100 @@
+package courier
+
+import (
+	"regexp"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+)
+
+// The descriptions of the host schema,
+// shared by the provider, the target data source and the deployment resource.
+const (
+	hostAuthnSecretDescription = `The secret to authenticate when accessing the target, 
+either password or private key, the private key pairs with the certificate if specified.`
+	hostAuthnAgentDescription = `Specify to access the target with agent,
+either SSH agent if type is "ssh" or NTLM if type is "winrm".`
+	hostAuthnPassphraseDescription = `The passphrase to decrypt the private key, 
+only works if type is "ssh".`
+	hostAuthnCertificateDescription = `The certificate signed for the private key, 
+either in the form of authorized_keys if type is "ssh", 
+or in the form of PEM if type is "winrm", which requires the address in the form of https://.`
+	hostAuthnPasswordDescription = `The password to authenticate after the agent and private key,
+only works if type is "ssh".`
+
+	hostBecomeDescription = `The privilege escalation of the runtime on the target, 
+only works if type is "ssh", guesses between root and sudo if not specified.`
+	hostBecomeMethodDescription = `The method to escalate, 
+either "sudo", "su", "doas" or "pbrun", defaults to "sudo".`
+	hostBecomeUserDescription     = `The user to become, defaults to "root".`
+	hostBecomePasswordDescription = `The password to escalate, 
+which is sent over stdin instead of the arguments if method is "sudo", 
+or answered in a pseudo terminal if method is "su", "doas" or "pbrun".`
+
+	hostKerberosDescription = `The Kerberos authentication of the user within the realm, 
+only works if type is "winrm", which requires the address in the form of https://, 
+as the messages are not encrypted by Kerberos, 
+authenticates with the secret as password if neither keytab nor ccache specified.`
+	hostKerberosRealmDescription    = `The realm of the user, e.g. "EXAMPLE.COM".`
+	hostKerberosKrb5ConfDescription = `The path of the krb5.conf, 
+defaults to $KRB5_CONFIG or "/etc/krb5.conf".`
+	hostKerberosKeytabDescription = `The path of the keytab to authenticate the user.`
+	hostKerberosCCacheDescription = `The path of the credential cache, 
+e.g. the one obtained by kinit.`
+	hostKerberosSPNDescription = `The service principal name of the target, 
+defaults to "HTTP/<host>".`
+
+	hostKeyKnownHostsDescription = `The path of the known_hosts file to verify,
+defaults to ~/.ssh/known_hosts if exists.`
+	hostKeyFingerprintsDescription = `The fingerprints to pin the host key, 
+in form of SHA256:base64 or MD5:hex.`
+
+	proxyAuthnUserDescription   = `The user to authenticate when accessing the proxy.`
+	proxyAuthnSecretDescription = `The secret to authenticate when accessing the proxy, 
+either password or private key.`
+	proxyAuthnCertificateDescription = `The certificate signed for the private key, 
+only works if type is "ssh", in the form of authorized_keys.`
+	proxyAuthnPasswordDescription = `The password to authenticate after the private key,
+only works if type is "ssh".`
+)
+
+// hostKeyDescription returns the description of the host key verification
+// for accessing the given subject, either "target" or "proxy".
+func hostKeyDescription(subject string) string {
+	return `The host key verification for accessing the ` + subject + `,
+only works if type is "ssh".`
+}
+
+// hostBecomeMethodValidators returns the validators of the escalation method.
+func hostBecomeMethodValidators() []validator.String {
+	return []validator.String{
+		stringvalidator.OneOf("sudo", "su", "doas", "pbrun"),
+	}
+}
+
+// hostBecomeUserValidators returns the validators of the user to become.
+func hostBecomeUserValidators() []validator.String {
+	return []validator.String{
+		stringvalidator.RegexMatches(
+			regexp.MustCompile(`^[\w.-]+$`),
+			"must be a valid user name",
+		),
+	}
+}
+
+// hostKerberosRealmValidators returns the validators of the Kerberos realm.
+func hostKerberosRealmValidators() []validator.String {
+	return []validator.String{
+		stringvalidator.LengthAtLeast(1),
+	}
+}
+
+// hostKerberosKeytabValidators returns the validators of the Kerberos keytab.
+func hostKerberosKeytabValidators() []validator.String {
+	return []validator.String{
+		stringvalidator.ConflictsWith(
+			path.MatchRelative().AtParent().AtName("ccache"),
+		),
+	}
+}
@@ -0,0 +1,2 @@
+# Import the deployment by the ID and the target addresses, which are accessed via SSH agent.
+terraform import courier_deployment.example "<id>,root@10.0.0.1,10.0.0.2:2222"
@@ -7,6 +7,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
 
 	"github.com/seal-io/terraform-provider-courier/courier"
+	"github.com/seal-io/terraform-provider-courier/pkg/target"
 	"github.com/seal-io/terraform-provider-courier/utils/signalx"
 )
 
@@ -21,14 +22,20 @@ func main() {
 	)
 	flag.Parse()
 
+	// Share the target hosts until the provider shuts down.
+	pool := target.NewPool()
+
 	err := providerserver.Serve(
 		signalx.Context(),
-		courier.NewProvider,
+		courier.NewProviderWithPool(pool),
 		providerserver.ServeOpts{
 			Address: courier.ProviderAddress,
 			Debug:   debug,
 		},
 	)
+
+	_ = pool.Close()
+
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -3,10 +3,14 @@ package conimg
 import (
 	"context"
 	"fmt"
+	"io"
+	"strings"
 
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
 
 	"github.com/seal-io/terraform-provider-courier/pkg/artifact/types"
 	"github.com/seal-io/terraform-provider-courier/utils/version"
@@ -72,3 +76,42 @@ func (p *Refer) State(ctx context.Context) (types.ReferStatus, error) {
 		Length:     d.Size,
 	}, nil
 }
+
+// Fetch writes the container image as a tarball which can be loaded by docker,
+// the image is pulled by the expected digest if given, which verifies the content.
+func (p *Refer) Fetch(ctx context.Context, w io.Writer, opts types.FetchOptions) error {
+	var (
+		ref        = p.ref
+		remoteOpts = append(p.opts, remote.WithContext(ctx))
+	)
+
+	if opts.Digest != "" {
+		ref = p.ref.Context().Digest(opts.Digest)
+	}
+
+	if opts.Platform != "" {
+		pf, err := v1.ParsePlatform(opts.Platform)
+		if err != nil {
+			return fmt.Errorf("failed to parse platform: %w", err)
+		}
+
+		remoteOpts = append(remoteOpts, remote.WithPlatform(*pf))
+	}
+
+	img, err := remote.Image(ref, remoteOpts...)
+	if err != nil {
+		return fmt.Errorf("failed to get image: %w", err)
+	}
+
+	// Tag the image to load, tags with the digest if the refer is not a tag.
+	tag, ok := p.ref.(name.Tag)
+	if !ok {
+		tag = p.ref.Context().Tag(strings.ReplaceAll(ref.Identifier(), ":", "-"))
+	}
+
+	if err = tarball.Write(tag, img, w); err != nil {
+		return fmt.Errorf("failed to write image: %w", err)
+	}
+
+	return nil
+}
@@ -3,12 +3,17 @@ package http
 import (
 	"context"
 	"crypto/sha256"
+	"crypto/sha512"
 	"crypto/tls"
+	"encoding/base64"
 	"encoding/hex"
 	"fmt"
+	gohash "hash"
 	"io"
 	"net"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/seal-io/terraform-provider-courier/pkg/artifact/types"
@@ -59,10 +64,92 @@ func New(opts types.ReferOptions) (types.Refer, error) {
 	}, nil
 }
 
+// packageStates caches the status of the packages by url for the provider's lifetime,
+// which saves the planning from downloading the unchanged package again.
+var packageStates sync.Map
+
+type packageState struct {
+	ETag         string
+	LastModified string
+	Status       types.ReferStatus
+}
+
+// validates returns true if the given headers of HEAD response match the cached state,
+// or the server does not support HEAD request.
+func (s packageState) validates(header http.Header) bool {
+	if header == nil {
+		return true
+	}
+
+	return s.ETag == header.Get("ETag") && s.LastModified == header.Get("Last-Modified")
+}
+
 func (p *Package) State(ctx context.Context) (types.ReferStatus, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	// Prefer the digest announced by the server,
+	// or the cached digest if the validators are unchanged.
+	var header http.Header
+
+	if resp, err := p.do(ctx, http.MethodHead); err == nil {
+		_ = resp.Body.Close()
+
+		header = resp.Header
+
+		if digest := digestFromHeader(header); digest != "" {
+			return types.ReferStatus{
+				Accessible: true,
+				Digest:     digest,
+				Type:       header.Get("Content-Type"),
+				Length:     resp.ContentLength,
+			}, nil
+		}
+	}
+
+	if v, ok := packageStates.Load(p.url); ok && v.(packageState).validates(header) {
+		return v.(packageState).Status, nil
+	}
+
+	resp, err := p.do(ctx, http.MethodGet)
+	if err != nil {
+		return types.ReferStatus{}, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	hash := sha256.New()
+
+	buf := bytespool.GetBytes()
+	defer func() { bytespool.Put(buf) }()
+
+	length, err := io.CopyBuffer(hash, resp.Body, buf)
 	if err != nil {
 		return types.ReferStatus{}, fmt.Errorf(
+			"failed to hash response: %w",
+			err,
+		)
+	}
+
+	digest := hex.EncodeToString(hash.Sum(nil))
+
+	s := packageState{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Status: types.ReferStatus{
+			Accessible: true,
+			Digest:     "sha256:" + digest,
+			Type:       resp.Header.Get("Content-Type"),
+			Length:     length,
+		},
+	}
+	packageStates.Store(p.url, s)
+
+	return s.Status, nil
+}
+
+// do sends the request of the given method to the package,
+// returns error if the response is not 200.
+func (p *Package) do(ctx context.Context, method string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, p.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf(
 			"failed to create request: %w",
 			err,
 		)
@@ -72,39 +159,86 @@ func (p *Package) State(ctx context.Context) (types.ReferStatus, error) {
 
 	resp, err := cli.Do(req)
 	if err != nil {
-		return types.ReferStatus{}, fmt.Errorf(
+		return nil, fmt.Errorf(
 			"failed to do request: %w",
 			err,
 		)
 	}
-	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
-		return types.ReferStatus{}, fmt.Errorf(
+		_ = resp.Body.Close()
+
+		return nil, fmt.Errorf(
 			"unexpected status code: %d",
 			resp.StatusCode,
 		)
 	}
 
-	hash := sha256.New()
+	return resp, nil
+}
+
+// digestFromHeader returns the sha256 digest announced by the Repr-Digest or Digest header,
+// e.g. "Repr-Digest: sha-256=:<base64>:" or "Digest: SHA-256=<base64>",
+// returns blank if not found.
+func digestFromHeader(header http.Header) string {
+	for _, k := range []string{"Repr-Digest", "Digest"} {
+		for _, v := range strings.Split(header.Get(k), ",") {
+			algo, value, ok := strings.Cut(strings.TrimSpace(v), "=")
+			if !ok || !strings.EqualFold(algo, "sha-256") {
+				continue
+			}
+
+			bs, err := base64.StdEncoding.DecodeString(strings.Trim(value, ":"))
+			if err != nil || len(bs) != sha256.Size {
+				continue
+			}
+
+			return "sha256:" + hex.EncodeToString(bs)
+		}
+	}
+
+	return ""
+}
+
+func (p *Package) Fetch(ctx context.Context, w io.Writer, opts types.FetchOptions) error {
+	algo, _, _ := strings.Cut(opts.Digest, ":")
+
+	var hash gohash.Hash
+
+	switch algo {
+	case "", "sha256":
+		algo, hash = "sha256", sha256.New()
+	case "sha224":
+		hash = sha256.New224()
+	case "sha384":
+		hash = sha512.New384()
+	case "sha512":
+		hash = sha512.New()
+	default:
+		return fmt.Errorf("unsupported digest algorithm %q", algo)
+	}
+
+	resp, err := p.do(ctx, http.MethodGet)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
 
 	buf := bytespool.GetBytes()
 	defer func() { bytespool.Put(buf) }()
 
-	length, err := io.CopyBuffer(hash, resp.Body, buf)
+	_, err = io.CopyBuffer(io.MultiWriter(w, hash), resp.Body, buf)
 	if err != nil {
-		return types.ReferStatus{}, fmt.Errorf(
-			"failed to hash response: %w",
+		return fmt.Errorf(
+			"failed to fetch response: %w",
 			err,
 		)
 	}
 
-	digest := hex.EncodeToString(hash.Sum(nil))
+	digest := algo + ":" + hex.EncodeToString(hash.Sum(nil))
+	if opts.Digest != "" && digest != opts.Digest {
+		return fmt.Errorf("mismatched digest %s, expected %s", digest, opts.Digest)
+	}
 
-	return types.ReferStatus{
-		Accessible: true,
-		Digest:     "sha256:" + digest,
-		Type:       resp.Header.Get("Content-Type"),
-		Length:     length,
-	}, nil
+	return nil
 }
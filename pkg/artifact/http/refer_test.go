@@ -0,0 +1,39 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seal-io/terraform-provider-courier/pkg/artifact/types"
+)
+
+func TestPackage_State(t *testing.T) {
+	var downloads int
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The sha256 of "hello".
+		w.Header().Set("Repr-Digest", "sha-512=:ignored:, sha-256=:LPJNul+wow4m6DsqxbninhsWHlwfp0JecwQzYpOLmCQ=:")
+
+		if r.Method == http.MethodGet {
+			downloads++
+		}
+
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+
+	p, err := New(types.ReferOptions{URI: srv.URL + "/app.war"})
+	if !assert.NoError(t, err, "should not return error") {
+		return
+	}
+
+	s, err := p.State(context.TODO())
+	if assert.NoError(t, err, "should not return error") {
+		assert.Equal(t, "sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", s.Digest)
+		assert.Equal(t, 0, downloads, "should not download")
+	}
+}
@@ -14,6 +14,13 @@ type (
 
 	ReferOptions     = types.ReferOptions
 	ReferOptionAuthn = types.ReferOptionAuthn
+
+	FetchOptions = types.FetchOptions
+)
+
+const (
+	ReferTypeHTTP           = types.ReferTypeHTTP
+	ReferTypeContainerImage = types.ReferTypeContainerImage
 )
 
 var ErrUnknownReferType = errors.New("unknown refer type")
@@ -2,6 +2,7 @@ package types
 
 import (
 	"context"
+	"io"
 	"net/url"
 	"strings"
 
@@ -12,6 +13,18 @@ type (
 	Refer interface {
 		// State returns the status of the reference.
 		State(ctx context.Context) (ReferStatus, error)
+		// Fetch writes the content of the reference to the given writer,
+		// returns error if the content mismatches the expected digest.
+		Fetch(ctx context.Context, w io.Writer, opts FetchOptions) error
+	}
+
+	FetchOptions struct {
+		// Digest is the expected digest of the reference,
+		// in form of algorithm:checksum.
+		Digest string
+		// Platform selects the container image of the given platform,
+		// in form of os/arch.
+		Platform string
 	}
 
 	ReferStatus struct {
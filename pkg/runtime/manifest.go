@@ -0,0 +1,182 @@
+package runtime
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// ManifestName is the name of the manifest file stored along with the runtime.
+const ManifestName = ".manifest"
+
+// Manifest records the digest of each file of the runtime,
+// keyed by the slash-separated path.
+type Manifest map[string]string
+
+// GetManifest computes the manifest of the given runtime source.
+func GetManifest(src Source) (Manifest, error) {
+	m := Manifest{}
+
+	err := fs.WalkDir(src, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() || p == ManifestName {
+			return nil
+		}
+
+		f, err := src.Open(p)
+		if err != nil {
+			return err
+		}
+
+		defer func() { _ = f.Close() }()
+
+		h := sha256.New()
+		if _, err = io.Copy(h, f); err != nil {
+			return err
+		}
+
+		m[p] = "sha256:" + hex.EncodeToString(h.Sum(nil))
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get manifest: %w", err)
+	}
+
+	return m, nil
+}
+
+// ParseManifest parses the manifest from the given reader,
+// which is in form of "digest path" per line.
+func ParseManifest(r io.Reader) (Manifest, error) {
+	m := Manifest{}
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" {
+			continue
+		}
+
+		dgst, p, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, fmt.Errorf("invalid manifest line: %q", line)
+		}
+
+		m[strings.TrimSpace(p)] = dgst
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+
+	return m, nil
+}
+
+// Bytes returns the manifest in form of "digest path" per line,
+// sorted by path.
+func (m Manifest) Bytes() []byte {
+	ps := make([]string, 0, len(m))
+	for p := range m {
+		ps = append(ps, p)
+	}
+
+	sort.Strings(ps)
+
+	var buf bytes.Buffer
+	for i := range ps {
+		_, _ = fmt.Fprintf(&buf, "%s %s\n", m[ps[i]], ps[i])
+	}
+
+	return buf.Bytes()
+}
+
+// Diff returns the entries which are not in the given manifest
+// or have different digests.
+func (m Manifest) Diff(l Manifest) Manifest {
+	d := Manifest{}
+
+	for p, dgst := range m {
+		if l[p] != dgst {
+			d[p] = dgst
+		}
+	}
+
+	return d
+}
+
+// FilterSource returns a source which only includes the files of the given manifest,
+// and the directories containing them.
+func FilterSource(src Source, m Manifest) Source {
+	dirs := map[string]struct{}{
+		".": {},
+	}
+
+	for p := range m {
+		for d := path.Dir(p); d != "."; d = path.Dir(d) {
+			dirs[d] = struct{}{}
+		}
+	}
+
+	return filteredSource{
+		Source: src,
+		files:  m,
+		dirs:   dirs,
+	}
+}
+
+type filteredSource struct {
+	Source
+
+	files Manifest
+	dirs  map[string]struct{}
+}
+
+func (s filteredSource) has(p string) bool {
+	if _, ok := s.files[p]; ok {
+		return true
+	}
+
+	_, ok := s.dirs[p]
+
+	return ok
+}
+
+func (s filteredSource) Open(name string) (fs.File, error) {
+	if !s.has(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return s.Source.Open(name)
+}
+
+func (s filteredSource) ReadDir(name string) ([]fs.DirEntry, error) {
+	if _, ok := s.dirs[name]; !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	ds, err := s.Source.ReadDir(name)
+	if err != nil {
+		return nil, err
+	}
+
+	r := make([]fs.DirEntry, 0, len(ds))
+
+	for i := range ds {
+		if s.has(path.Join(name, ds[i].Name())) {
+			r = append(r, ds[i])
+		}
+	}
+
+	return r, nil
+}
@@ -0,0 +1,59 @@
+package runtime
+
+import (
+	"bytes"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManifest(t *testing.T) {
+	src := fstest.MapFS{
+		"lib/service.sh":          {Data: []byte("lib")},
+		"docker/linux/service.sh": {Data: []byte("docker")},
+		"tomcat/linux/service.sh": {Data: []byte("tomcat")},
+	}
+
+	local, err := GetManifest(src)
+	if !assert.NoError(t, err, "should not return error") {
+		return
+	}
+
+	assert.Len(t, local, 3)
+
+	remote, err := ParseManifest(bytes.NewReader(local.Bytes()))
+	if !assert.NoError(t, err, "should not return error") {
+		return
+	}
+
+	assert.Equal(t, local, remote)
+	assert.Empty(t, local.Diff(remote))
+
+	src["tomcat/linux/service.sh"] = &fstest.MapFile{Data: []byte("tomcat2")}
+
+	changed, err := GetManifest(src)
+	if !assert.NoError(t, err, "should not return error") {
+		return
+	}
+
+	diff := changed.Diff(remote)
+	assert.Equal(t, Manifest{
+		"tomcat/linux/service.sh": changed["tomcat/linux/service.sh"],
+	}, diff)
+
+	var walked []string
+
+	err = fs.WalkDir(FilterSource(src, diff), ".",
+		func(p string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				walked = append(walked, p)
+			}
+
+			return err
+		})
+	if assert.NoError(t, err, "should not return error") {
+		assert.Equal(t, []string{"tomcat/linux/service.sh"}, walked)
+	}
+}
@@ -11,7 +11,7 @@ for file in "${ROOT_DIR}/lib/linux/"*; do
   fi
 done
 
-COURIER_PATH="/var/local/courier/artifact"
+COURIER_PATH="$(dirname "${ROOT_DIR}")/artifact"
 
 #
 # Stages
@@ -64,34 +64,71 @@ setup() {
   if [ -z "${dck_img}" ]; then
     log "FATAL" "Missing docker image"
   fi
-  if [ -n "${refer_authn_user}" ] && [ -n "${refer_authn_secret}" ]; then
-    dck_reg="index.docker.io"
-    if [ -n "$(echo "${dck_img}" | cut -d'/' -f3)" ]; then
-      dck_reg="$(echo "${dck_img}" | cut -d'/' -f1)"
+  require_digest "${refer_digest}"
+  case "${dck_img}" in
+  file://*)
+    ### Load the image tarball pushed by the provider.
+    dck_tar="${dck_img#file://}"
+    if ! checksum "${dck_tar}" "${refer_digest}"; then
+      log "FATAL" "Corrupted docker image tarball"
     fi
-    ${rc} "docker login --username ${refer_authn_user} --password ${refer_authn_secret} ${dck_reg}"
-  fi
-  ${rc} "docker pull --quiet ${dck_img}"
+    dck_img="$(${rc} "docker load --quiet --input ${dck_tar}" | sed -n 's/^Loaded image: //p' | tail -n 1)"
+    if [ -z "${dck_img}" ]; then
+      log "FATAL" "Cannot load docker image tarball"
+    fi
+    ;;
+  *)
+    case "${refer_digest}" in
+    sha256:*) ;;
+    *) log "FATAL" "Unsupported docker image digest '${refer_digest}'" ;;
+    esac
+    ### Pull by digest, which fails if the digest mismatches.
+    case "${dck_img}" in
+    *@*)
+      if [ "${dck_img##*@}" != "${refer_digest}" ]; then
+        log "FATAL" "Mismatched docker image digest '${dck_img##*@}', expected '${refer_digest}'"
+      fi
+      ;;
+    *) dck_img="${dck_img}@${refer_digest}" ;;
+    esac
+    if [ -n "${refer_authn_user}" ] && [ -n "${refer_authn_secret}" ]; then
+      dck_reg="index.docker.io"
+      if [ -n "$(echo "${dck_img}" | cut -d'/' -f3)" ]; then
+        dck_reg="$(echo "${dck_img}" | cut -d'/' -f1)"
+      fi
+      ${rc} "docker login --username ${refer_authn_user} --password ${refer_authn_secret} ${dck_reg}"
+    fi
+    ${rc} "docker pull --quiet ${dck_img}"
+    ;;
+  esac
 
   ##
   ## Prepare
   ##
-  dck_cmd="docker create --restart always --name ${art}"
+  apply_files "${COURIER_PATH}/${art}"
+
+  ### Create aside to keep the running container until start.
+  dck_cmd="docker create --restart always --name ${art}-next"
 
   if [ -f "${COURIER_PATH}/${art}/ports" ]; then
     while read -r port; do
-      if [ -n "${port}" ]; then
-        dck_cmd="${dck_cmd} --publish ${port}:${port}"
-      fi
+      case "${port}" in
+      "") ;;
+      *:*) dck_cmd="${dck_cmd} --publish ${port}" ;;
+      *) dck_cmd="${dck_cmd} --publish ${port}:${port}" ;;
+      esac
     done <"${COURIER_PATH}/${art}/ports"
   fi
 
-  if [ -f "${COURIER_PATH}/${art}/envs" ]; then
-    while read -r env; do
-      if [ -n "${env}" ]; then
-        dck_cmd="${dck_cmd} --env ${env}"
-      fi
-    done <"${COURIER_PATH}/${art}/envs"
+  if [ -s "${COURIER_PATH}/${art}/envs" ]; then
+    ### Pass by file to read the values as is, the same as the secret envs.
+    dck_cmd="${dck_cmd} --env-file ${COURIER_PATH}/${art}/envs"
+  fi
+
+  if [ -s "${COURIER_PATH}/${art}/secret_envs" ]; then
+    ### Pass by file to keep the secrets out of the process arguments.
+    secure_file "${COURIER_PATH}/${art}/secret_envs"
+    dck_cmd="${dck_cmd} --env-file ${COURIER_PATH}/${art}/secret_envs"
   fi
 
   if [ -f "${COURIER_PATH}/${art}/volumes" ]; then
@@ -114,6 +151,9 @@ setup() {
   ##
   ## Create
   ##
+  if ${rc} "docker inspect --type container ${art}-next" >/dev/null 2>&1; then
+    ${rc} "docker remove --force ${art}-next"
+  fi
   ${rc} "${dck_cmd}"
 }
 
@@ -126,6 +166,14 @@ start() {
 
   rc=$(root_call)
 
+  ### Replace the previous container with the one created by setup.
+  if ${rc} "docker inspect --type container ${art}-next" >/dev/null 2>&1; then
+    if ${rc} "docker inspect --type container ${art}" >/dev/null 2>&1; then
+      ${rc} "docker remove --force ${art}"
+    fi
+    ${rc} "docker rename ${art}-next ${art}"
+  fi
+
   ${rc} "docker start ${art}"
 }
 
@@ -138,7 +186,35 @@ state() {
 
   rc=$(root_call)
 
-  ${rc} "docker inspect ${art}"
+  if ! ${rc} "docker inspect --type container ${art}" >/dev/null 2>&1; then
+    echo "status=missing"
+    return 0
+  fi
+
+  status="stopped"
+  if [ "$(${rc} "docker inspect --type container --format '{{.State.Status}}' ${art}")" = "running" ]; then
+    status="running"
+  fi
+  echo "status=${status}"
+
+  dck_img="$(${rc} "docker inspect --type container --format '{{.Image}}' ${art}")"
+  if [ -z "${dck_img}" ]; then
+    log "FATAL" "Cannot inspect the image of container '${art}'"
+  fi
+  digest="$(${rc} "docker image inspect --format '{{range .RepoDigests}}{{println .}}{{end}}' ${dck_img}" | head -n 1 | cut -d'@' -f2)"
+  if [ -z "${digest}" ] && [ -s "${COURIER_PATH}/${art}/pushed" ]; then
+    ### The image loaded from the pushed tarball has no repo digest, report the pushed manifest digest.
+    digest="$(cut -d' ' -f1 <"${COURIER_PATH}/${art}/pushed")"
+  fi
+  if [ -z "${digest}" ]; then
+    ### Fall back to the image id.
+    digest="${dck_img}"
+  fi
+  echo "digest=${digest}"
+
+  if [ "${status}" = "running" ]; then
+    echo "started_at=$(${rc} "docker inspect --type container --format '{{.State.StartedAt}}' ${art}")"
+  fi
 }
 
 ## service.sh stop "${artifact_id}"
@@ -162,7 +238,7 @@ cleanup() {
 
   rc=$(root_call)
 
-  ${rc} "docker remove --force --volumes ${art}"
+  ${rc} "docker remove --force --volumes ${art} ${art}-next || true"
   ${rc} "rm -rf ${COURIER_PATH}/${art}"
 }
 
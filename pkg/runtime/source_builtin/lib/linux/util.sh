@@ -45,24 +45,131 @@ log() {
   fi
 }
 
+# Run the given command on exit before the existing exit trap.
+trap_exit() {
+  exit_cmd="${1:-}"
+  # List the traps in the current shell, as some shells reset the traps in the subshell.
+  exit_trap_file="$(mktemp)"
+  trap >"${exit_trap_file}"
+  exit_trap="$(grep ' EXIT$' "${exit_trap_file}" || true)"
+  rm -f "${exit_trap_file}"
+  if [ -n "${exit_trap}" ]; then
+    # Unquote the existing trap, which is printed in form of "trap -- 'command' EXIT".
+    eval "set -- ${exit_trap}"
+    exit_cmd="${exit_cmd}; ${3}"
+  fi
+  # shellcheck disable=SC2064
+  trap "${exit_cmd}" EXIT
+}
+
+# Prepare the askpass helper for sudo right before running the given command,
+# which answers the become password and keeps it out of the process arguments.
+sudo_askpass() {
+  if [ ! -x "${SUDO_ASKPASS:-}" ]; then
+    SUDO_ASKPASS="$(mktemp)"
+    trap_exit "rm -f '${SUDO_ASKPASS}'"
+    chmod 0700 "${SUDO_ASKPASS}"
+    cat <<'EOF' >"${SUDO_ASKPASS}"
+#!/bin/sh
+printf '%s\n' "${COURIER_BECOME_PASSWORD}"
+EOF
+    export SUDO_ASKPASS
+  fi
+  "$@"
+}
+
+# Mark the escalation on the terminal right before running the given command,
+# the provider answers the password prompt only if it follows the mark.
+become_tty() {
+  if [ -n "${COURIER_BECOME_TTY_MARK:-}" ] && { true >/dev/tty; } 2>/dev/null; then
+    printf '%s\n' "${COURIER_BECOME_TTY_MARK}" >/dev/tty
+  fi
+  "$@"
+}
+
 root_call() {
   usr="$(id -un 2>/dev/null || true)"
+  become_method="${COURIER_BECOME_METHOD:-}"
+  become_user="${COURIER_BECOME_USER:-root}"
 
   cmd='sh -c'
 
-  if [ "${usr}" != 'root' ]; then
+  if [ "${usr}" = "${become_user}" ]; then
+    echo "${cmd}"
+    return 0
+  fi
+
+  # Guess between root and sudo if not specified.
+  if [ -z "${become_method}" ]; then
     if command_exists sudo; then
-      cmd='sudo -E sh -c'
+      become_method="sudo"
     elif command_exists su; then
-      cmd='su -c'
+      become_method="su"
     else
       log "FATAL" "User '${usr}' cannot use root_call"
     fi
   fi
 
+  if ! command_exists "${become_method}"; then
+    log "FATAL" "Become method '${become_method}' is not available"
+  fi
+  if [ -n "${COURIER_BECOME_PASSWORD:-}" ] && [ "${become_method}" != "sudo" ]; then
+    log "FATAL" "Become method '${become_method}' cannot use password"
+  fi
+
+  case "${become_method}" in
+  sudo)
+    if [ -n "${COURIER_BECOME_PASSWORD:-}" ]; then
+      # Answer by the askpass helper, and hide the password from the privileged commands.
+      cmd="sudo_askpass sudo -E -A -u ${become_user} env -u COURIER_BECOME_PASSWORD sh -c"
+    else
+      cmd="sudo -E -u ${become_user} sh -c"
+    fi
+    ;;
+  su) cmd="become_tty su ${become_user} -c" ;;
+  doas) cmd="become_tty doas -u ${become_user} sh -c" ;;
+  pbrun) cmd="become_tty pbrun -u ${become_user} sh -c" ;;
+  *) log "FATAL" "Unsupported become method '${become_method}'" ;;
+  esac
+
   echo "${cmd}"
 }
 
+secure_file() {
+  file="${1:-}"
+  if [ -z "${file}" ]; then
+    log "FATAL" "Missing file"
+  fi
+
+  # Only readable by the become user, which runs the service.
+  $(root_call) "chown ${COURIER_BECOME_USER:-root} ${file} && chmod 0600 ${file}"
+}
+
+apply_files() {
+  art_dir="${1:-}"
+  if [ -z "${art_dir}" ]; then
+    log "FATAL" "Missing artifact directory"
+  fi
+
+  if [ ! -f "${art_dir}/files" ]; then
+    return 0
+  fi
+
+  rc=$(root_call)
+
+  # Copy the staged files to the destination, each line in form of "index mode owner path".
+  while read -r idx mode owner path; do
+    if [ -z "${path}" ]; then
+      continue
+    fi
+    file_dest="${art_dir}/${path}"
+    ${rc} "mkdir -p $(dirname "${file_dest}") && cp -f ${art_dir}/files.d/${idx} ${file_dest} && chmod ${mode} ${file_dest}"
+    if [ "${owner}" != "-" ]; then
+      ${rc} "chown ${owner} ${file_dest}"
+    fi
+  done <"${art_dir}/files"
+}
+
 checksum() {
   archive="${1:-}"
   if [ -z "${archive}" ]; then
@@ -92,6 +199,16 @@ checksum() {
   return 1
 }
 
+require_digest() {
+  digest="${1:-}"
+
+  case "${digest}" in
+  "") log "FATAL" "Missing artifact digest" ;;
+  sha256:* | sha224:* | sha384:* | sha512:*) ;;
+  *) log "FATAL" "Unsupported artifact digest '${digest}'" ;;
+  esac
+}
+
 uncompress() {
   archive="${1:-}"
   if [ -z "${archive}" ]; then
@@ -234,7 +351,10 @@ download() {
   ${rc} "${mkdir_cmd}"
 
   download_cmd=""
-  if command_exists curl; then
+  if [ "${uri#file://}" != "${uri}" ]; then
+    # The file pushed by the provider.
+    download_cmd="cp -f \"${uri#file://}\" \"${dest}\""
+  elif command_exists curl; then
     case "${authn_type}" in
     basic) download_cmd="curl --user \"${authn_user}:${authn_secret}\" --retry 3 --retry-all-errors --retry-delay 3 -fsSL -o \"${dest}\" \"${uri}\"" ;;
     bearer) download_cmd="curl --header \"Authorization: Bearer ${authn_secret}\" --retry 3 --retry-all-errors --retry-delay 3 -fsSL -o \"${dest}\" \"${uri}\"" ;;
@@ -258,3 +378,9 @@ download() {
     fi
   fi
 }
+
+# Read the become password from stdin once, which is sent by the provider.
+if [ "${COURIER_BECOME_PASSWORD_STDIN:-}" = "true" ] && [ -z "${COURIER_BECOME_PASSWORD:-}" ]; then
+  IFS= read -r COURIER_BECOME_PASSWORD || true
+  export COURIER_BECOME_PASSWORD
+fi
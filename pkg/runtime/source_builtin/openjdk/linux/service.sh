@@ -11,7 +11,7 @@ for file in "${ROOT_DIR}/lib/linux/"*; do
   fi
 done
 
-COURIER_PATH="/var/local/courier/artifact"
+COURIER_PATH="$(dirname "${ROOT_DIR}")/artifact"
 
 SYSTEMD_PATH="/etc/systemd/system"
 
@@ -77,6 +77,7 @@ setup() {
   if [ -z "${uri}" ]; then
     log "FATAL" "Missing refer URI"
   fi
+  require_digest "${refer_digest}"
   dest="${COURIER_PATH}/${art}/target.jar"
   download "${uri}" "${dest}" "${refer_digest}" "${refer_authn_type}" "${refer_authn_user}" "${refer_authn_secret}"
 
@@ -85,15 +86,49 @@ setup() {
   ##
   ${rc} "chown -R $(id -u):$(id -g) ${COURIER_PATH}/${art}"
 
-  mkdir "${COURIER_PATH}/${art}/bin"
+  ### Listen on the published port if the first declared port is remapped in form of publish:port,
+  ### e.g. by the blue/green strategy, which is passed as server.port,
+  ### and all listening ports are exported as COURIER_PORTS.
+  server_port=""
+  ports=""
+  if [ -f "${COURIER_PATH}/${art}/ports" ]; then
+    first="true"
+    while read -r port; do
+      if [ -z "${port}" ]; then
+        continue
+      fi
+      if [ "${first}" = "true" ] && [ "${port#*:}" != "${port}" ]; then
+        server_port="${port%%:*}"
+      fi
+      first="false"
+      ports="${ports:+${ports} }${port%%:*}"
+    done <"${COURIER_PATH}/${art}/ports"
+  fi
+  java_opts=""
+  if [ -n "${server_port}" ]; then
+    java_opts="-Dserver.port=${server_port}"
+  fi
+
+  mkdir -p "${COURIER_PATH}/${art}/bin"
   cat <<EOF >"${COURIER_PATH}/${art}/bin/startup.sh"
 #!/bin/sh
 
+export COURIER_PORTS="${ports}"
+
+secret_envs="\${CREDENTIALS_DIRECTORY:-${COURIER_PATH}/${art}}/secret_envs"
+if [ -f "\${secret_envs}" ]; then
+  while IFS= read -r env; do
+    if [ -n "\${env}" ]; then
+      export "\${env}"
+    fi
+  done <"\${secret_envs}"
+fi
+
 command=""
 if [ -f "${COURIER_PATH}/${art}/command" ]; then
   command=$(cat <"${COURIER_PATH}/${art}/command" | sed -e 's/^[[:space:]]*//' -e 's/[[:space:]]*$//')
 fi
-java -jar ${COURIER_PATH}/${art}/target.jar "\${command}"
+java ${java_opts} -jar ${COURIER_PATH}/${art}/target.jar "\${command}"
 EOF
   cat <<EOF >"${COURIER_PATH}/${art}/bin/shutdown.sh"
 #!/bin/sh
@@ -102,9 +137,18 @@ jps -lv | grep ${COURIER_PATH}/${art}/target.jar | awk '{print \$1}' | xargs kil
 EOF
   chmod a+x "${COURIER_PATH}/${art}/bin/"*.sh
 
+  apply_files "${COURIER_PATH}/${art}"
+
   ##
   ## Create
   ##
+  load_credential=""
+  if [ -s "${COURIER_PATH}/${art}/secret_envs" ]; then
+    ### Load by systemd credentials to keep the secrets out of the process arguments.
+    secure_file "${COURIER_PATH}/${art}/secret_envs"
+    load_credential="LoadCredential=secret_envs:${COURIER_PATH}/${art}/secret_envs"
+  fi
+
   reload="n"
   if [ -e "${SYSTEMD_PATH}/openjdk-${art}.service" ]; then
     reload="y"
@@ -127,6 +171,7 @@ ExecStart=${COURIER_PATH}/${art}/bin/startup.sh
 ExecStop=${COURIER_PATH}/${art}/bin/shutdown.sh
 
 EnvironmentFile=${COURIER_PATH}/${art}/envs
+${load_credential}
 EOF
   if [ ! -e "${SYSTEMD_PATH}/openjdk-${art}.service" ]; then
     ${rc} "mkdir -p ${SYSTEMD_PATH}"
@@ -157,9 +202,29 @@ state() {
     log "FATAL" "Missing artifact"
   fi
 
+  if [ ! -e "${SYSTEMD_PATH}/openjdk-${art}.service" ]; then
+    echo "status=missing"
+    return 0
+  fi
+
   rc=$(root_call)
 
-  ${rc} "systemctl status openjdk-${art}.service"
+  status="stopped"
+  if [ "$(${rc} "systemctl show --property=ActiveState openjdk-${art}.service" | cut -d'=' -f2)" = "active" ]; then
+    status="running"
+  fi
+  echo "status=${status}"
+
+  if [ -f "${COURIER_PATH}/${art}/target.jar" ]; then
+    echo "digest=sha256:$(${rc} "sha256sum ${COURIER_PATH}/${art}/target.jar" | cut -d' ' -f1)"
+  fi
+
+  if [ "${status}" = "running" ]; then
+    started_at="$(${rc} "systemctl show --property=ActiveEnterTimestamp openjdk-${art}.service" | cut -d'=' -f2)"
+    if [ -n "${started_at}" ]; then
+      echo "started_at=$(date -u -d "${started_at}" +"%Y-%m-%dT%H:%M:%SZ")"
+    fi
+  fi
 }
 
 ## service.sh stop "${artifact_id}"
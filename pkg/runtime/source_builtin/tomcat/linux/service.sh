@@ -11,7 +11,7 @@ for file in "${ROOT_DIR}/lib/linux/"*; do
   fi
 done
 
-COURIER_PATH="/var/local/courier/artifact"
+COURIER_PATH="$(dirname "${ROOT_DIR}")/artifact"
 
 SYSTEMD_PATH="/etc/systemd/system"
 
@@ -79,6 +79,7 @@ setup() {
   if [ -z "${uri}" ]; then
     log "FATAL" "Missing refer URI"
   fi
+  require_digest "${refer_digest}"
   dest="${COURIER_PATH}/${art}/tomcat/webapps/ROOT.war"
   download "${uri}" "${dest}" "${refer_digest}" "${refer_authn_type}" "${refer_authn_user}" "${refer_authn_secret}"
 
@@ -86,6 +87,18 @@ setup() {
   ## Prepare
   ##
   ${rc} "chown -R $(id -u):$(id -g) ${COURIER_PATH}/${art}"
+  cat <<EOF >"${COURIER_PATH}/${art}/tomcat/bin/setenv.sh"
+#!/bin/sh
+
+secret_envs="\${CREDENTIALS_DIRECTORY:-${COURIER_PATH}/${art}}/secret_envs"
+if [ -f "\${secret_envs}" ]; then
+  while IFS= read -r env; do
+    if [ -n "\${env}" ]; then
+      export "\${env}"
+    fi
+  done <"\${secret_envs}"
+fi
+EOF
   chmod a+x "${COURIER_PATH}/${art}/tomcat/bin/"*.sh
 
   cat <<EOF >"${COURIER_PATH}/${art}/tomcat/conf/server.xml"
@@ -102,6 +115,8 @@ EOF
   if [ -f "${COURIER_PATH}/${art}/ports" ]; then
     while read -r port; do
       if [ -n "${port}" ]; then
+        # Listen on the published port if in form of publish:port.
+        port="${port%%:*}"
         cat <<EOF >>"${COURIER_PATH}/${art}/tomcat/conf/server.xml"
             <Connector port="${port}" maxThreads="1000" protocol="HTTP/1.1" connectionTimeout="20000" />
 EOF
@@ -117,9 +132,18 @@ EOF
 </Server>
 EOF
 
+  apply_files "${COURIER_PATH}/${art}"
+
   ##
   ## Create
   ##
+  load_credential=""
+  if [ -s "${COURIER_PATH}/${art}/secret_envs" ]; then
+    ### Load by systemd credentials to keep the secrets out of the process arguments.
+    secure_file "${COURIER_PATH}/${art}/secret_envs"
+    load_credential="LoadCredential=secret_envs:${COURIER_PATH}/${art}/secret_envs"
+  fi
+
   reload="n"
   if [ -e "${SYSTEMD_PATH}/tomcat-${art}.service" ]; then
     reload="y"
@@ -145,6 +169,7 @@ EnvironmentFile=${COURIER_PATH}/${art}/envs
 Environment="CATALINA_BASE=${COURIER_PATH}/${art}/tomcat"
 Environment="CATALINA_HOME=${COURIER_PATH}/${art}/tomcat"
 Environment="CATALINA_PID=${COURIER_PATH}/${art}/tomcat/temp/tomcat.pid"
+${load_credential}
 EOF
   if [ ! -e "${SYSTEMD_PATH}/tomcat-${art}.service" ]; then
     ${rc} "mkdir -p ${SYSTEMD_PATH}"
@@ -175,9 +200,29 @@ state() {
     log "FATAL" "Missing artifact"
   fi
 
+  if [ ! -e "${SYSTEMD_PATH}/tomcat-${art}.service" ]; then
+    echo "status=missing"
+    return 0
+  fi
+
   rc=$(root_call)
 
-  ${rc} "systemctl status tomcat-${art}.service"
+  status="stopped"
+  if [ "$(${rc} "systemctl show --property=ActiveState tomcat-${art}.service" | cut -d'=' -f2)" = "active" ]; then
+    status="running"
+  fi
+  echo "status=${status}"
+
+  if [ -f "${COURIER_PATH}/${art}/tomcat/webapps/ROOT.war" ]; then
+    echo "digest=sha256:$(${rc} "sha256sum ${COURIER_PATH}/${art}/tomcat/webapps/ROOT.war" | cut -d' ' -f1)"
+  fi
+
+  if [ "${status}" = "running" ]; then
+    started_at="$(${rc} "systemctl show --property=ActiveEnterTimestamp tomcat-${art}.service" | cut -d'=' -f2)"
+    if [ -n "${started_at}" ]; then
+      echo "started_at=$(date -u -d "${started_at}" +"%Y-%m-%dT%H:%M:%SZ")"
+    fi
+  fi
 }
 
 ## service.sh stop "${artifact_id}"
@@ -0,0 +1,226 @@
+package codec
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/seal-io/terraform-provider-courier/pkg/target/types"
+	"github.com/seal-io/terraform-provider-courier/utils/bytespool"
+)
+
+// ErrShellClosed is returned if the terminal output ends before the command finishes.
+var ErrShellClosed = errors.New("shell closed")
+
+// escapeRegexp matches the control sequences written by the pseudo terminal,
+// e.g. the bracketed paste mode of bash.
+var escapeRegexp = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// ShellReader reads the output of the terminal line by line,
+// the lines of the given streams are interleaved in arrival order,
+// and the prompts are answered by the responders without waiting for the line feed.
+type ShellReader struct {
+	lines  chan string
+	done   chan struct{}
+	answer io.Writer
+	resps  []types.ShellResponder
+	armed  []bool
+	pty    bool
+
+	m    sync.Mutex
+	err  error
+	rest []byte
+	once sync.Once
+}
+
+// NewShellReader returns a ShellReader reading the given streams,
+// the answers of the responders are written to the given writer,
+// which must be safe for concurrent use.
+func NewShellReader(
+	answer io.Writer,
+	opts types.ShellOptions,
+	streams ...io.Reader,
+) *ShellReader {
+	r := &ShellReader{
+		lines:  make(chan string, 64),
+		done:   make(chan struct{}),
+		answer: answer,
+		resps:  opts.Responders,
+		armed:  make([]bool, len(opts.Responders)),
+		pty:    opts.PTY,
+	}
+
+	var g sync.WaitGroup
+
+	for i := range streams {
+		g.Add(1)
+
+		go func(rd io.Reader) {
+			defer g.Done()
+
+			r.read(rd)
+		}(streams[i])
+	}
+
+	go func() {
+		g.Wait()
+		close(r.lines)
+	}()
+
+	return r
+}
+
+// ReadLine returns the next line without the line feed,
+// or ErrShellClosed if all streams end.
+func (r *ShellReader) ReadLine() (string, error) {
+	l, ok := <-r.lines
+	if ok {
+		return l, nil
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if r.err != nil {
+		return "", r.err
+	}
+
+	return "", ErrShellClosed
+}
+
+// Read implements io.Reader, which returns the lines with the line feed.
+func (r *ShellReader) Read(p []byte) (int, error) {
+	if len(r.rest) == 0 {
+		l, err := r.ReadLine()
+		if err != nil {
+			if errors.Is(err, ErrShellClosed) {
+				err = io.EOF
+			}
+
+			return 0, err
+		}
+
+		r.rest = append(r.rest[:0], l...)
+		r.rest = append(r.rest, '\n')
+	}
+
+	n := copy(p, r.rest)
+	r.rest = r.rest[n:]
+
+	return n, nil
+}
+
+// Close stops delivering the lines,
+// the streams are drained until they end.
+func (r *ShellReader) Close() error {
+	r.once.Do(func() { close(r.done) })
+	return nil
+}
+
+func (r *ShellReader) read(rd io.Reader) {
+	buf := bytespool.GetBytes()
+	defer func() { bytespool.Put(buf) }()
+
+	var pending []byte
+
+	for {
+		n, err := rd.Read(buf)
+
+		for _, b := range buf[:n] {
+			if b != '\n' {
+				pending = append(pending, b)
+				continue
+			}
+
+			r.deliver(string(pending))
+			pending = pending[:0]
+		}
+
+		// Answer the prompt which does not end with line feed.
+		if len(pending) != 0 && r.respond(string(pending)) {
+			r.deliver(string(pending))
+			pending = pending[:0]
+		}
+
+		if err != nil {
+			if len(pending) != 0 {
+				r.deliver(string(pending))
+			}
+
+			if !errors.Is(err, io.EOF) {
+				r.m.Lock()
+				if r.err == nil {
+					r.err = err
+				}
+				r.m.Unlock()
+			}
+
+			return
+		}
+	}
+}
+
+// respond writes the answer of the first responder matched the given output,
+// returns true if answered.
+func (r *ShellReader) respond(output string) bool {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	for i := range r.resps {
+		if r.resps[i].Prompt == nil || !r.resps[i].Prompt.MatchString(output) {
+			continue
+		}
+
+		if r.resps[i].Trigger != "" {
+			if !r.armed[i] {
+				continue
+			}
+
+			r.armed[i] = false
+		}
+
+		_, _ = io.WriteString(r.answer, r.resps[i].Answer+"\n")
+
+		return true
+	}
+
+	return false
+}
+
+// trigger arms the responders triggered by the given line and disarms the others,
+// returns true if the line is a trigger.
+func (r *ShellReader) trigger(line string) bool {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	var triggered bool
+
+	for i := range r.resps {
+		if r.resps[i].Trigger == "" {
+			continue
+		}
+
+		r.armed[i] = r.resps[i].Trigger == line
+		triggered = triggered || r.armed[i]
+	}
+
+	return triggered
+}
+
+func (r *ShellReader) deliver(line string) {
+	line = strings.TrimSuffix(line, "\r")
+	if r.pty {
+		line = escapeRegexp.ReplaceAllString(line, "")
+	}
+
+	if r.trigger(line) {
+		return
+	}
+
+	select {
+	case <-r.done:
+	case r.lines <- line:
+	}
+}
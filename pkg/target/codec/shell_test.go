@@ -0,0 +1,105 @@
+package codec
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seal-io/terraform-provider-courier/pkg/target/types"
+	"github.com/seal-io/terraform-provider-courier/utils/iox"
+)
+
+func TestShellReader(t *testing.T) {
+	var (
+		answer   bytes.Buffer
+		ord, owr = io.Pipe()
+		erd, ewr = io.Pipe()
+	)
+
+	opts := types.NewShellOptions(
+		types.WithShellResponder(regexp.MustCompile(`password for \S+: $`), "secret"))
+	r := NewShellReader(iox.SingleWriter(&answer), opts, ord, erd)
+
+	expectedLine := func(expected string) {
+		t.Helper()
+
+		actual, err := r.ReadLine()
+		if assert.NoError(t, err, "should not return error") {
+			assert.Equal(t, expected, actual)
+		}
+	}
+
+	// Read stderr while stdout is still open.
+	_, _ = io.WriteString(ewr, "warning\n")
+	expectedLine("warning")
+
+	// Interleave in arrival order.
+	_, _ = io.WriteString(owr, "out\r\n")
+	expectedLine("out")
+	_, _ = io.WriteString(ewr, "err\n")
+	expectedLine("err")
+
+	// Answer the prompt without line feed.
+	_, _ = io.WriteString(ewr, "[sudo] password for foo: ")
+	expectedLine("[sudo] password for foo: ")
+	assert.Equal(t, "secret\n", answer.String())
+
+	// Deliver the rest at the end.
+	_, _ = io.WriteString(owr, "rest")
+	_ = owr.Close()
+	_ = ewr.Close()
+	expectedLine("rest")
+
+	_, err := r.ReadLine()
+	assert.ErrorIs(t, err, ErrShellClosed)
+}
+
+func TestShellReader_trigger(t *testing.T) {
+	var (
+		answer bytes.Buffer
+		rd, wr = io.Pipe()
+	)
+
+	opts := types.NewShellOptions(
+		types.WithShellTriggeredResponder("#mark#", regexp.MustCompile(`(?i)password: $`), "secret"))
+	r := NewShellReader(iox.SingleWriter(&answer), opts, rd)
+
+	expectedLine := func(expected string) {
+		t.Helper()
+
+		actual, err := r.ReadLine()
+		if assert.NoError(t, err, "should not return error") {
+			assert.Equal(t, expected, actual)
+		}
+	}
+
+	// Ignore the prompt without the trigger.
+	_, _ = io.WriteString(wr, "Password: ")
+	_, _ = io.WriteString(wr, "\n")
+	expectedLine("Password: ")
+	assert.Equal(t, "", answer.String())
+
+	// Answer the prompt following the trigger, and swallow the trigger.
+	_, _ = io.WriteString(wr, "#mark#\n")
+	_, _ = io.WriteString(wr, "Password: ")
+	expectedLine("Password: ")
+	assert.Equal(t, "secret\n", answer.String())
+
+	// Answer only once per trigger.
+	_, _ = io.WriteString(wr, "Password: ")
+	_, _ = io.WriteString(wr, "\n")
+	expectedLine("Password: ")
+	assert.Equal(t, "secret\n", answer.String())
+
+	// Disarm on the next line.
+	_, _ = io.WriteString(wr, "#mark#\n")
+	_, _ = io.WriteString(wr, "hello\n")
+	expectedLine("hello")
+	_, _ = io.WriteString(wr, "Password: ")
+	_ = wr.Close()
+	expectedLine("Password: ")
+	assert.Equal(t, "secret\n", answer.String())
+}
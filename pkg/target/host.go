@@ -1,7 +1,9 @@
 package target
 
 import (
+	"context"
 	"errors"
+	"io/fs"
 
 	"github.com/seal-io/terraform-provider-courier/pkg/target/ssh"
 	"github.com/seal-io/terraform-provider-courier/pkg/target/types"
@@ -12,11 +14,34 @@ type (
 	Host       = types.Host
 	HostStatus = types.HostStatus
 
-	HostOptions     = types.HostOptions
-	HostOption      = types.HostOption
-	HostOptionAuthn = types.HostOptionAuthn
+	HostOptions        = types.HostOptions
+	HostOption         = types.HostOption
+	HostOptionAuthn    = types.HostOptionAuthn
+	HostOptionBecome   = types.HostOptionBecome
+	HostOptionKerberos = types.HostOptionKerberos
+	HostOptionHostKey  = types.HostOptionHostKey
+
+	ExitError = types.ExitError
+
+	TransmitOption   = types.TransmitOption
+	TransmitProgress = types.TransmitProgress
 )
 
+// WithBecome escalates the commands executed with the returned context.
+func WithBecome(ctx context.Context) context.Context {
+	return types.WithBecome(ctx)
+}
+
+// WithTransmitMode changes the permission of the uploaded file.
+func WithTransmitMode(mode fs.FileMode) TransmitOption {
+	return types.WithTransmitMode(mode)
+}
+
+// WithTransmitProgress reports the progress of the uploading to the given function.
+func WithTransmitProgress(fn func(TransmitProgress)) TransmitOption {
+	return types.WithTransmitProgress(fn)
+}
+
 var ErrUnknownHostAuthnType = errors.New("unknown host authn type")
 
 func NewHost(opts HostOptions) (Host, error) {
@@ -0,0 +1,302 @@
+package target
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.uber.org/multierr"
+
+	"github.com/seal-io/terraform-provider-courier/pkg/target/proxy"
+	"github.com/seal-io/terraform-provider-courier/pkg/target/ssh"
+	"github.com/seal-io/terraform-provider-courier/pkg/target/types"
+)
+
+// Pool shares the hosts with the same options,
+// and the hops with the same options behind the same previous hops,
+// the pooled host or hop is closed only when the pool closes,
+// or reconnected if its connection is broken.
+type Pool struct {
+	m     sync.Mutex
+	hosts map[string]*pooledEntry
+	hops  map[string]*pooledHop
+
+	// newHost creates the host, defaults to NewHost.
+	newHost func(HostOptions) (Host, error)
+	// dialHop connects to the hop via the given forward, defaults to dialHop.
+	dialHop func(types.DialCloser, HostOption) (types.DialCloser, error)
+}
+
+type pooledEntry struct {
+	m    sync.Mutex
+	host Host
+
+	// trusted records the fingerprints trusted on first use by hop,
+	// which are replayed to the callers sharing the host.
+	trusted map[int]string
+}
+
+type pooledHop struct {
+	m       sync.Mutex
+	forward types.DialCloser
+
+	// trusted records the fingerprint trusted on first use,
+	// which is replayed to the callers sharing the hop.
+	trusted string
+}
+
+func NewPool() *Pool {
+	return &Pool{
+		hosts:   map[string]*pooledEntry{},
+		hops:    map[string]*pooledHop{},
+		newHost: NewHost,
+		dialHop: dialHop,
+	}
+}
+
+// Get returns the host with the given options,
+// which is shared if the pool is not nil, closing the returned host does nothing.
+func (p *Pool) Get(opts HostOptions) (Host, error) {
+	if p == nil {
+		return NewHost(opts)
+	}
+
+	key := poolKey(opts)
+
+	p.m.Lock()
+	e, ok := p.hosts[key]
+	if !ok {
+		e = &pooledEntry{}
+		p.hosts[key] = e
+	}
+	p.m.Unlock()
+
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	if e.host != nil {
+		ka, ok := e.host.(types.KeepAliver)
+		if !ok || ka.KeepAlive() == nil {
+			// Replay the trusted fingerprints,
+			// as the pooled host does not handshake again.
+			for i, o := range hopsOf(&opts) {
+				if fp, ok := e.trusted[i]; ok && o.HostKey.Trusted != nil {
+					o.HostKey.Trusted(fp)
+				}
+			}
+
+			return pooledHost{Host: e.host}, nil
+		}
+
+		// Reconnect if broken.
+		_ = e.host.Close()
+		e.host = nil
+	}
+
+	// Record the trusted fingerprints without changing the given proxies.
+	trusted := map[int]string{}
+	opts.Proxies = append([]HostOption(nil), opts.Proxies...)
+
+	for i, o := range hopsOf(&opts) {
+		i, fn := i, o.HostKey.Trusted
+		o.HostKey.Trusted = func(fp string) {
+			trusted[i] = fp
+
+			if fn != nil {
+				fn(fp)
+			}
+		}
+	}
+
+	if len(opts.Proxies) != 0 {
+		fwd, err := p.forward(opts.Proxies)
+		if err != nil {
+			return nil, err
+		}
+
+		opts.Forward = fwd
+	}
+
+	h, err := p.newHost(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	e.host = h
+	e.trusted = trusted
+
+	return pooledHost{Host: h}, nil
+}
+
+// forward returns the dialer via the given proxies,
+// which shares each hop with the same options behind the same previous hops.
+func (p *Pool) forward(proxies []HostOption) (types.DialCloser, error) {
+	var (
+		fwd    types.DialCloser = types.DialClosers{}
+		redial bool
+	)
+
+	for i := range proxies {
+		key := poolKey(HostOptions{HostOption: proxies[i], Proxies: proxies[:i]})
+
+		p.m.Lock()
+		e, ok := p.hops[key]
+		if !ok {
+			e = &pooledHop{}
+			p.hops[key] = e
+		}
+		p.m.Unlock()
+
+		var err error
+
+		// Redial the following hops if the previous one is redialed,
+		// which forward via the previous connection.
+		fwd, redial, err = e.get(fwd, proxies[i], redial, p.dialHop)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return fwd, nil
+}
+
+// get returns the pooled hop if alive and the given redial is false,
+// otherwise connects to the hop via the given forward again,
+// returns true if connected again.
+func (e *pooledHop) get(
+	fwd types.DialCloser,
+	hop HostOption,
+	redial bool,
+	dial func(types.DialCloser, HostOption) (types.DialCloser, error),
+) (types.DialCloser, bool, error) {
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	if e.forward != nil {
+		if !redial {
+			ka, ok := e.forward.(types.KeepAliver)
+			if !ok || ka.KeepAlive() == nil {
+				if e.trusted != "" && hop.HostKey.Trusted != nil {
+					hop.HostKey.Trusted(e.trusted)
+				}
+
+				return e.forward, false, nil
+			}
+		}
+
+		// Reconnect if broken.
+		_ = e.forward.Close()
+		e.forward = nil
+	}
+
+	var trusted string
+
+	fn := hop.HostKey.Trusted
+	hop.HostKey.Trusted = func(fp string) {
+		trusted = fp
+
+		if fn != nil {
+			fn(fp)
+		}
+	}
+
+	d, err := dial(fwd, hop)
+	if err != nil {
+		return nil, false, err
+	}
+
+	e.forward = d
+	e.trusted = trusted
+
+	return d, true, nil
+}
+
+// dialHop connects to the given hop via the given forward.
+func dialHop(fwd types.DialCloser, hop HostOption) (types.DialCloser, error) {
+	switch hop.Authn.Type {
+	case "ssh":
+		return ssh.DialForward(fwd, hop)
+	case "proxy":
+		return proxy.Dial(fwd, hop)
+	}
+
+	return nil, errors.New("unknown host type")
+}
+
+// hopsOf returns the options of each hop, the target host goes last.
+func hopsOf(opts *HostOptions) []*HostOption {
+	hops := make([]*HostOption, 0, len(opts.Proxies)+1)
+	for i := range opts.Proxies {
+		hops = append(hops, &opts.Proxies[i])
+	}
+
+	return append(hops, &opts.HostOption)
+}
+
+// Close closes all pooled hosts.
+func (p *Pool) Close() (err error) {
+	if p == nil {
+		return nil
+	}
+
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	for k, e := range p.hosts {
+		e.m.Lock()
+		if e.host != nil {
+			err = multierr.Append(err, e.host.Close())
+		}
+		e.m.Unlock()
+
+		delete(p.hosts, k)
+	}
+
+	for k, e := range p.hops {
+		e.m.Lock()
+		if e.forward != nil {
+			err = multierr.Append(err, e.forward.Close())
+		}
+		e.m.Unlock()
+
+		delete(p.hops, k)
+	}
+
+	return err
+}
+
+// poolKey returns the key of the given options,
+// which is composed of the address, the credential and the verification of each hop.
+func poolKey(opts HostOptions) string {
+	var sb strings.Builder
+
+	for _, o := range append(opts.Proxies[:len(opts.Proxies):len(opts.Proxies)], opts.HostOption) {
+		au := o.Authn
+		hk := o.HostKey
+		kb := au.Kerberos
+
+		cred := sha256.Sum256([]byte(strings.Join(
+			[]string{au.Secret, au.Passphrase, au.Certificate, au.Password, au.Become.Password, o.CACert}, "\x00")))
+
+		_, _ = fmt.Fprintf(&sb, "%s|%s|%s|%s|%t|%t|%s|%s|%t|%s|%s|%s|%s|%s|%s|%s\n",
+			au.Type, o.Address, au.User, hex.EncodeToString(cred[:]), au.Agent,
+			o.Insecure, hk.KnownHosts, strings.Join(hk.Fingerprints, ","), hk.TrustOnFirstUse,
+			au.Become.Method, au.Become.User,
+			kb.Realm, kb.Config, kb.Keytab, kb.CCache, kb.SPN)
+	}
+
+	return sb.String()
+}
+
+// pooledHost is the host shared by the pool,
+// which does not close the underlying host.
+type pooledHost struct {
+	Host
+}
+
+func (pooledHost) Close() error {
+	return nil
+}
@@ -0,0 +1,208 @@
+package target
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seal-io/terraform-provider-courier/pkg/target/types"
+)
+
+type fakeHost struct {
+	Host
+
+	broken bool
+	closed bool
+}
+
+func (h *fakeHost) KeepAlive() error {
+	if h.broken {
+		return errors.New("broken")
+	}
+
+	return nil
+}
+
+func (h *fakeHost) Close() error {
+	h.closed = true
+	return nil
+}
+
+func TestPool(t *testing.T) {
+	var created []*fakeHost
+
+	p := NewPool()
+	p.newHost = func(opts HostOptions) (Host, error) {
+		h := &fakeHost{}
+		created = append(created, h)
+
+		// Trust the unknown host key during handshake.
+		if hk := opts.HostKey; hk.TrustOnFirstUse && hk.Trusted != nil {
+			hk.Trusted("SHA256:x")
+		}
+
+		return h, nil
+	}
+
+	opts := HostOptions{
+		HostOption: HostOption{
+			Address: "10.0.0.1",
+			Authn:   HostOptionAuthn{Type: "ssh", User: "root", Secret: "x"},
+		},
+	}
+
+	// Share the host with the same options.
+	h1, err := p.Get(opts)
+	if !assert.NoError(t, err, "should not return error") {
+		return
+	}
+
+	_, _ = p.Get(opts)
+	assert.Len(t, created, 1)
+
+	// Closing the pooled host does nothing.
+	assert.NoError(t, h1.Close())
+	assert.False(t, created[0].closed)
+
+	// Create another host with different credential.
+	opts2 := opts
+	opts2.Authn.Secret = "y"
+	_, _ = p.Get(opts2)
+	assert.Len(t, created, 2)
+
+	// Create another host with different escalation.
+	opts3 := opts
+	opts3.Authn.Become = HostOptionBecome{Method: "sudo", User: "root", Password: "z"}
+	_, _ = p.Get(opts3)
+	assert.Len(t, created, 3)
+
+	// Create another host with different Kerberos realm.
+	opts4 := opts
+	opts4.Authn.Kerberos = HostOptionKerberos{Realm: "EXAMPLE.COM"}
+	_, _ = p.Get(opts4)
+	assert.Len(t, created, 4)
+
+	// Replay the fingerprint trusted on first use to the callers sharing the host.
+	var trusted []string
+
+	opts5 := opts
+	opts5.HostKey = HostOptionHostKey{TrustOnFirstUse: true}
+
+	for i := 0; i < 2; i++ {
+		opts5.HostKey.Trusted = func(fp string) { trusted = append(trusted, fp) }
+		_, _ = p.Get(opts5)
+	}
+	assert.Len(t, created, 5)
+	assert.Equal(t, []string{"SHA256:x", "SHA256:x"}, trusted)
+
+	// Reconnect if broken.
+	created[0].broken = true
+	_, _ = p.Get(opts)
+	assert.Len(t, created, 6)
+	assert.True(t, created[0].closed)
+
+	// Close all hosts.
+	assert.NoError(t, p.Close())
+	assert.True(t, created[1].closed)
+	assert.True(t, created[2].closed)
+	assert.True(t, created[3].closed)
+	assert.True(t, created[4].closed)
+	assert.True(t, created[5].closed)
+}
+
+type fakeHop struct {
+	broken bool
+	closed bool
+}
+
+func (h *fakeHop) Dial(_, _ string) (net.Conn, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (h *fakeHop) KeepAlive() error {
+	if h.broken {
+		return errors.New("broken")
+	}
+
+	return nil
+}
+
+func (h *fakeHop) Close() error {
+	h.closed = true
+	return nil
+}
+
+func TestPool_hops(t *testing.T) {
+	var (
+		created []*fakeHost
+		dialed  []*fakeHop
+	)
+
+	p := NewPool()
+	p.newHost = func(opts HostOptions) (Host, error) {
+		h := &fakeHost{}
+		created = append(created, h)
+
+		return h, nil
+	}
+	p.dialHop = func(_ types.DialCloser, hop HostOption) (types.DialCloser, error) {
+		h := &fakeHop{}
+		dialed = append(dialed, h)
+
+		// Trust the unknown host key during handshake.
+		if hk := hop.HostKey; hk.TrustOnFirstUse && hk.Trusted != nil {
+			hk.Trusted("SHA256:" + hop.Address)
+		}
+
+		return h, nil
+	}
+
+	var trusted []string
+
+	newOpts := func(address string) HostOptions {
+		return HostOptions{
+			HostOption: HostOption{
+				Address: address,
+				Authn:   HostOptionAuthn{Type: "ssh", User: "root", Secret: "x"},
+			},
+			Proxies: []HostOption{
+				{
+					Address: "10.0.0.254",
+					Authn:   HostOptionAuthn{Type: "ssh", User: "jump", Secret: "x"},
+					HostKey: HostOptionHostKey{
+						TrustOnFirstUse: true,
+						Trusted:         func(fp string) { trusted = append(trusted, fp) },
+					},
+				},
+			},
+		}
+	}
+
+	// Share the hop between the hosts behind the same jump host.
+	_, err := p.Get(newOpts("10.0.0.1"))
+	if !assert.NoError(t, err, "should not return error") {
+		return
+	}
+
+	_, _ = p.Get(newOpts("10.0.0.2"))
+	assert.Len(t, created, 2)
+	assert.Len(t, dialed, 1)
+
+	// Replay the fingerprint trusted on first use to the callers sharing the hop.
+	assert.Equal(t, []string{"SHA256:10.0.0.254", "SHA256:10.0.0.254"}, trusted)
+
+	// Reconnect the hop if broken.
+	dialed[0].broken = true
+	created[1].broken = true
+	_, _ = p.Get(newOpts("10.0.0.2"))
+	assert.Len(t, created, 3)
+	assert.Len(t, dialed, 2)
+	assert.True(t, dialed[0].closed)
+
+	// Close all hosts and hops.
+	assert.NoError(t, p.Close())
+	assert.True(t, created[2].closed)
+	assert.True(t, dialed[1].closed)
+}
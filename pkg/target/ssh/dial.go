@@ -6,11 +6,15 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
 
 	"github.com/seal-io/terraform-provider-courier/pkg/target/types"
+	"github.com/seal-io/terraform-provider-courier/utils/osx"
 )
 
 func Dial(
@@ -22,41 +26,23 @@ func Dial(
 		return nil, fmt.Errorf("failed to parse proxy address: %w", err)
 	}
 
-	var auths []ssh.AuthMethod
-
-	if dialHost.Authn.Agent {
-		agentConn, err := net.Dial("unix", os.Getenv("SSH_AUTH_SOCK"))
-		if err != nil {
-			return nil, fmt.Errorf("failed to connect SSH agent: %w", err)
-		}
-
-		auths = append(
-			auths,
-			ssh.PublicKeysCallback(agent.NewClient(agentConn).Signers),
-		)
-	} else if dialHost.Authn.Secret != "" {
-		pb, _ := pem.Decode([]byte(dialHost.Authn.Secret))
-
-		if pb == nil {
-			auths = append(auths, ssh.Password(dialHost.Authn.Secret))
-		} else {
-			if pb.Headers["Proc-Type"] == "4,ENCRYPTED" {
-				return nil, errors.New("encrypted private key is not supported")
-			}
+	auths, release, err := getAuthMethods(dialHost.Authn)
+	if err != nil {
+		return nil, err
+	}
 
-			singer, err := ssh.ParsePrivateKey([]byte(dialHost.Authn.Secret))
-			if err != nil {
-				return nil, fmt.Errorf("faield to parse private key: %w", err)
-			}
+	// The agent is only used during the handshake.
+	defer release()
 
-			auths = append(auths, ssh.PublicKeys(singer))
-		}
+	hostKeyCallback, err := getHostKeyCallback(dialHost)
+	if err != nil {
+		return nil, err
 	}
 
 	cfg := &ssh.ClientConfig{
 		User:            dialHost.Authn.User,
 		Auth:            auths,
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(), //nolint:gosec
+		HostKeyCallback: hostKeyCallback,
 	}
 
 	addr := ap.HostPort(22)
@@ -80,3 +66,209 @@ func Dial(
 
 	return ssh.NewClient(conn, nextCh, nextReq), nil
 }
+
+// getAuthMethods returns the auth methods in order of agent, private key and password,
+// the client tries each method only once,
+// so the signers and the passwords are walked within a single method respectively,
+// the returned release closes the agent connection after authenticating.
+func getAuthMethods(
+	authn types.HostOptionAuthn,
+) (auths []ssh.AuthMethod, release func(), err error) {
+	var (
+		signers   []ssh.Signer
+		passwords []string
+		agentCli  agent.ExtendedAgent
+	)
+
+	release = func() {}
+
+	if authn.Agent {
+		agentConn, err := net.Dial("unix", os.Getenv("SSH_AUTH_SOCK"))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to connect SSH agent: %w", err)
+		}
+
+		agentCli = agent.NewClient(agentConn)
+		release = func() { _ = agentConn.Close() }
+	}
+
+	if authn.Secret != "" {
+		if pb, _ := pem.Decode([]byte(authn.Secret)); pb == nil {
+			passwords = append(passwords, authn.Secret)
+		} else {
+			signers, err = getSigners(authn)
+			if err != nil {
+				release()
+				return nil, nil, err
+			}
+		}
+	}
+
+	if agentCli != nil || len(signers) != 0 {
+		auths = append(auths, ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+			if agentCli == nil {
+				return signers, nil
+			}
+
+			agentSigners, err := agentCli.Signers()
+			if err != nil && len(signers) == 0 {
+				return nil, fmt.Errorf("failed to list SSH agent keys: %w", err)
+			}
+
+			return append(agentSigners, signers...), nil
+		}))
+	}
+
+	if authn.Password != "" {
+		passwords = append(passwords, authn.Password)
+	}
+
+	if len(passwords) != 0 {
+		var tried int
+
+		auths = append(auths, ssh.RetryableAuthMethod(
+			ssh.PasswordCallback(func() (string, error) {
+				if tried >= len(passwords) {
+					return "", errors.New("no more passwords")
+				}
+
+				tried++
+
+				return passwords[tried-1], nil
+			}),
+			len(passwords),
+		))
+	}
+
+	return auths, release, nil
+}
+
+// getSigners returns the signers of the private key,
+// the certificate signer goes first if the certificate is provided.
+func getSigners(
+	authn types.HostOptionAuthn,
+) ([]ssh.Signer, error) {
+	var (
+		signer ssh.Signer
+		err    error
+	)
+
+	if authn.Passphrase != "" {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(
+			[]byte(authn.Secret),
+			[]byte(authn.Passphrase),
+		)
+	} else {
+		signer, err = ssh.ParsePrivateKey([]byte(authn.Secret))
+	}
+
+	if err != nil {
+		var pme *ssh.PassphraseMissingError
+		if errors.As(err, &pme) {
+			return nil, errors.New("encrypted private key requires passphrase")
+		}
+
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	if authn.Certificate == "" {
+		return []ssh.Signer{signer}, nil
+	}
+
+	pk, _, _, _, err := ssh.ParseAuthorizedKey([]byte(authn.Certificate))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+
+	cert, ok := pk.(*ssh.Certificate)
+	if !ok {
+		return nil, errors.New("invalid certificate")
+	}
+
+	certSigner, err := ssh.NewCertSigner(cert, signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign with certificate: %w", err)
+	}
+
+	return []ssh.Signer{certSigner, signer}, nil
+}
+
+func getHostKeyCallback(
+	dialHost types.HostOption,
+) (ssh.HostKeyCallback, error) {
+	if dialHost.Insecure {
+		return ssh.InsecureIgnoreHostKey(), nil //nolint:gosec
+	}
+
+	hk := dialHost.HostKey
+
+	knownHostsPath := hk.KnownHosts
+	if knownHostsPath == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			p := filepath.Join(home, ".ssh", "known_hosts")
+			if osx.Exists(p) {
+				knownHostsPath = p
+			}
+		}
+	}
+
+	var knownHostsCallback ssh.HostKeyCallback
+
+	if knownHostsPath != "" {
+		var err error
+
+		knownHostsCallback, err = knownhosts.New(knownHostsPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read known hosts: %w", err)
+		}
+	}
+
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		// Verify with the pinned fingerprints.
+		if len(hk.Fingerprints) != 0 {
+			for i := range hk.Fingerprints {
+				if matchFingerprint(hk.Fingerprints[i], key) {
+					return nil
+				}
+			}
+
+			return fmt.Errorf("host key of %s mismatched, got %s",
+				hostname, ssh.FingerprintSHA256(key))
+		}
+
+		// Verify with the known hosts,
+		// and only fall through if the host is unknown.
+		if knownHostsCallback != nil {
+			err := knownHostsCallback(hostname, remote, key)
+
+			var ke *knownhosts.KeyError
+			if !errors.As(err, &ke) || len(ke.Want) != 0 {
+				return err
+			}
+		}
+
+		if hk.TrustOnFirstUse {
+			if hk.Trusted != nil {
+				hk.Trusted(ssh.FingerprintSHA256(key))
+			}
+
+			return nil
+		}
+
+		return fmt.Errorf("host key of %s is unknown, got %s",
+			hostname, ssh.FingerprintSHA256(key))
+	}, nil
+}
+
+func matchFingerprint(fingerprint string, key ssh.PublicKey) bool {
+	fingerprint = strings.TrimSpace(fingerprint)
+
+	if strings.HasPrefix(fingerprint, "SHA256:") {
+		return strings.TrimRight(fingerprint, "=") == ssh.FingerprintSHA256(key)
+	}
+
+	return strings.EqualFold(
+		strings.TrimPrefix(fingerprint, "MD5:"),
+		ssh.FingerprintLegacyMD5(key),
+	)
+}
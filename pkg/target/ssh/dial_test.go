@@ -0,0 +1,252 @@
+package ssh
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+
+	"github.com/seal-io/terraform-provider-courier/pkg/target/types"
+)
+
+func TestDial_AuthMethods(t *testing.T) {
+	goodKey, goodPEM := generateKey(t)
+	badKey, badPEM := generateKey(t)
+
+	goodPub, err := ssh.NewPublicKey(&goodKey.PublicKey)
+	if !assert.NoError(t, err, "should not return error") {
+		return
+	}
+
+	// Serve an agent holding the key rejected by the server.
+	sock := filepath.Join(t.TempDir(), "agent.sock")
+
+	agentLn, err := net.Listen("unix", sock)
+	if !assert.NoError(t, err, "should not return error") {
+		return
+	}
+
+	defer func() { _ = agentLn.Close() }()
+
+	keyring := agent.NewKeyring()
+	_ = keyring.Add(agent.AddedKey{PrivateKey: badKey})
+
+	// Notify when the client closes the agent connection.
+	released := make(chan struct{}, 8)
+
+	go func() {
+		for {
+			c, err := agentLn.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				_ = agent.ServeAgent(keyring, c)
+				released <- struct{}{}
+			}()
+		}
+	}()
+
+	t.Setenv("SSH_AUTH_SOCK", sock)
+
+	testCases := []struct {
+		name     string
+		authn    types.HostOptionAuthn
+		expected []string
+		wantErr  bool
+	}{
+		{
+			name: "agent rejected then private key",
+			authn: types.HostOptionAuthn{
+				Agent:  true,
+				Secret: goodPEM,
+			},
+			expected: []string{"publickey:bad", "publickey:good"},
+		},
+		{
+			name: "private key rejected then password",
+			authn: types.HostOptionAuthn{
+				Agent:    true,
+				Secret:   badPEM,
+				Password: "good",
+			},
+			expected: []string{"publickey:bad", "password:good"},
+		},
+		{
+			name: "password rejected then password",
+			authn: types.HostOptionAuthn{
+				Secret:   "bad",
+				Password: "good",
+			},
+			expected: []string{"password:bad", "password:good"},
+		},
+		{
+			name: "all rejected",
+			authn: types.HostOptionAuthn{
+				Secret:   badPEM,
+				Password: "bad",
+			},
+			expected: []string{"publickey:bad", "password:bad"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				m     sync.Mutex
+				tried []string
+			)
+
+			record := func(s string) {
+				m.Lock()
+				defer m.Unlock()
+
+				// Ignore the query before signing.
+				if len(tried) == 0 || tried[len(tried)-1] != s {
+					tried = append(tried, s)
+				}
+			}
+
+			cfg := &ssh.ServerConfig{
+				PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+					if bytes.Equal(key.Marshal(), goodPub.Marshal()) {
+						record("publickey:good")
+						return &ssh.Permissions{}, nil
+					}
+
+					record("publickey:bad")
+
+					return nil, errors.New("rejected")
+				},
+				PasswordCallback: func(_ ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+					record("password:" + string(password))
+
+					if string(password) == "good" {
+						return &ssh.Permissions{}, nil
+					}
+
+					return nil, errors.New("rejected")
+				},
+			}
+			cfg.AddHostKey(mustSigner(t, goodKey))
+
+			addr := serveSSH(t, cfg, nil)
+
+			tc.authn.Type = "ssh"
+			tc.authn.User = "root"
+
+			cli, err := Dial(nil, types.HostOption{
+				Address:  addr,
+				Authn:    tc.authn,
+				Insecure: true,
+			})
+
+			if tc.authn.Agent {
+				select {
+				case <-released:
+				case <-time.After(5 * time.Second):
+					assert.Fail(t, "should close the agent connection after dialing")
+				}
+			}
+
+			if tc.wantErr {
+				assert.Error(t, err, "should return error")
+			} else if assert.NoError(t, err, "should not return error") {
+				_ = cli.Close()
+			}
+
+			m.Lock()
+			defer m.Unlock()
+
+			assert.Equal(t, tc.expected, tried)
+		})
+	}
+}
+
+func generateKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bs, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key, string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: bs}))
+}
+
+func mustSigner(t *testing.T, key *ecdsa.PrivateKey) ssh.Signer {
+	t.Helper()
+
+	s, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+// serveSSH serves the SSH handshake with the given configuration,
+// handles the channels with the given function if not nil,
+// and returns the listening address.
+func serveSSH(t *testing.T, cfg *ssh.ServerConfig, handle func(ssh.NewChannel)) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer func() { _ = c.Close() }()
+
+				sc, chans, reqs, err := ssh.NewServerConn(c, cfg)
+				if err != nil {
+					return
+				}
+
+				defer func() { _ = sc.Close() }()
+
+				go ssh.DiscardRequests(reqs)
+
+				for ch := range chans {
+					if handle == nil {
+						_ = ch.Reject(ssh.Prohibited, "not supported")
+						continue
+					}
+
+					go handle(ch)
+				}
+			}()
+		}
+	}()
+
+	return ln.Addr().String()
+}
@@ -4,8 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"net"
+	"regexp"
 	"strings"
+	"time"
 
+	"github.com/apparentlymart/go-shquot/shquot"
+	"go.uber.org/multierr"
 	"golang.org/x/crypto/ssh"
 
 	"github.com/seal-io/terraform-provider-courier/pkg/target/codec"
@@ -18,7 +24,9 @@ import (
 
 type Host struct {
 	client   *ssh.Client
+	forward  types.DialCloser
 	platform string
+	become   types.HostOptionBecome
 }
 
 func New(opts types.HostOptions) (types.Host, error) {
@@ -30,13 +38,23 @@ func New(opts types.HostOptions) (types.Host, error) {
 		return nil, errors.New("no address specified")
 	}
 
-	proxies, err := proxyWith(types.DialClosers{}, opts.Proxies)
-	if err != nil {
-		return nil, fmt.Errorf(
-			"failed to dail %s via proxies: %w",
-			opts.Address,
-			err,
-		)
+	var (
+		proxies types.DialCloser
+		err     error
+	)
+
+	if opts.Forward != nil {
+		// Share the forward without closing.
+		proxies = types.DialCloserFunc(opts.Forward.Dial)
+	} else {
+		proxies, err = proxyWith(types.DialClosers{}, opts.Proxies)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"failed to dail %s via proxies: %w",
+				opts.Address,
+				err,
+			)
+		}
 	}
 
 	c, err := Dial(proxies, opts.HostOption)
@@ -46,7 +64,9 @@ func New(opts types.HostOptions) (types.Host, error) {
 
 	return &Host{
 		client:   c,
+		forward:  proxies,
 		platform: "linux",
+		become:   opts.Authn.Become,
 	}, nil
 }
 
@@ -82,7 +102,89 @@ func proxyWith(
 }
 
 func (h *Host) Close() error {
-	return h.client.Close()
+	return multierr.Append(h.client.Close(), h.forward.Close())
+}
+
+// KeepAlive sends a keepalive request to the host,
+// returns error if the connection is broken or the host does not reply in time.
+func (h *Host) KeepAlive() error {
+	return keepAlive(h.client)
+}
+
+// keepAliveTimeout is the timeout of waiting for the reply of the keepalive request.
+const keepAliveTimeout = 15 * time.Second
+
+// keepAlive sends a keepalive request over the given client,
+// returns error if the client does not reply within keepAliveTimeout.
+func keepAlive(c *ssh.Client) error {
+	rc := make(chan error, 1)
+
+	go func() {
+		_, _, err := c.SendRequest("keepalive@openssh.com", true, nil)
+		rc <- err
+	}()
+
+	t := time.NewTimer(keepAliveTimeout)
+	defer t.Stop()
+
+	select {
+	case err := <-rc:
+		return err
+	case <-t.C:
+		return errors.New("keepalive timeout")
+	}
+}
+
+// Forward is the client connected to the hop,
+// which forwards the connections to the next hop or the target host.
+type Forward struct {
+	*ssh.Client
+}
+
+// DialForward connects to the given hop via the given forward.
+func DialForward(forward types.DialCloser, hop types.HostOption) (Forward, error) {
+	c, err := Dial(forward, hop)
+	if err != nil {
+		return Forward{}, err
+	}
+
+	return Forward{Client: c}, nil
+}
+
+// KeepAlive sends a keepalive request to the hop,
+// returns error if the connection is broken or the hop does not reply in time.
+func (f Forward) KeepAlive() error {
+	return keepAlive(f.Client)
+}
+
+func (h *Host) Dial(
+	ctx context.Context,
+	network, address string,
+) (net.Conn, error) {
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+
+	rc := make(chan result, 1)
+
+	go func() {
+		c, err := h.client.Dial(network, address)
+		rc <- result{conn: c, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		go func() {
+			if r := <-rc; r.conn != nil {
+				_ = r.conn.Close()
+			}
+		}()
+
+		return nil, ctx.Err()
+	case r := <-rc:
+		return r.conn, r.err
+	}
 }
 
 func (h *Host) State(ctx context.Context) (types.HostStatus, error) {
@@ -147,6 +249,10 @@ func (h *Host) Execute(
 		return errors.New("blank command")
 	}
 
+	if h.becomeOnTTY(ctx) {
+		return h.executeOnTTY(ctx, io.Discard, cmd, args)
+	}
+
 	s, err := h.getSessionWithContext(ctx)
 	if err != nil {
 		return err
@@ -154,7 +260,7 @@ func (h *Host) Execute(
 
 	defer func() { _ = s.Close() }()
 
-	command := codec.EncodeExecInput(h.platform, cmd, args)
+	command := h.encodeExecInput(ctx, s, cmd, args)
 
 	return s.Run(command)
 }
@@ -168,6 +274,15 @@ func (h *Host) ExecuteWithOutput(
 		return nil, errors.New("blank command")
 	}
 
+	if h.becomeOnTTY(ctx) {
+		buf := bytespool.GetBuffer()
+		defer func() { bytespool.Put(buf) }()
+
+		err := h.executeOnTTY(ctx, buf, cmd, args)
+
+		return append([]byte(nil), buf.Bytes()...), err
+	}
+
 	s, err := h.getSessionWithContext(ctx)
 	if err != nil {
 		return nil, err
@@ -175,11 +290,108 @@ func (h *Host) ExecuteWithOutput(
 
 	defer func() { _ = s.Close() }()
 
-	command := codec.EncodeExecInput(h.platform, cmd, args)
+	command := h.encodeExecInput(ctx, s, cmd, args)
 
 	return s.CombinedOutput(command)
 }
 
+func (h *Host) ExecuteWithStream(
+	ctx context.Context,
+	out io.Writer,
+	cmd string,
+	args ...string,
+) error {
+	if cmd == "" {
+		return errors.New("blank command")
+	}
+
+	if h.becomeOnTTY(ctx) {
+		return h.executeOnTTY(ctx, out, cmd, args)
+	}
+
+	s, err := h.getSessionWithContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = s.Close() }()
+
+	command := h.encodeExecInput(ctx, s, cmd, args)
+
+	wr := iox.SingleWriter(out)
+	s.Stdout = wr
+	s.Stderr = wr
+
+	return s.Run(command)
+}
+
+// encodeExecInput encodes the given command,
+// and passes the escalation to the runtime by environment variables if the context escalates,
+// the password is sent over stdin to keep it out of the process arguments.
+func (h *Host) encodeExecInput(ctx context.Context, s *session, cmd string, args []string) string {
+	command := codec.EncodeExecInput(h.platform, cmd, args)
+
+	b := h.become
+	if !types.BecomeFrom(ctx) || (b.Method == "" && b.User == "" && b.Password == "") {
+		return command
+	}
+
+	envs := []string{
+		"COURIER_BECOME_METHOD=" + shquot.POSIXShell([]string{b.Method}),
+		"COURIER_BECOME_USER=" + shquot.POSIXShell([]string{b.User}),
+	}
+
+	if b.Password != "" {
+		envs = append(envs, "COURIER_BECOME_PASSWORD_STDIN=true")
+		s.Stdin = strings.NewReader(b.Password + "\n")
+	}
+
+	return strings.Join(envs, " ") + " " + command
+}
+
+// becomePromptRegexp matches the password prompt of the escalation methods,
+// e.g. "Password: " of su or "doas (foo@bar) password: " of doas.
+var becomePromptRegexp = regexp.MustCompile(`(?i)password[^\n]*:\s*$`)
+
+// becomeOnTTY returns true if the context escalates,
+// and the escalation method reads the password from the terminal,
+// i.e. all methods except sudo, which reads the password from stdin.
+func (h *Host) becomeOnTTY(ctx context.Context) bool {
+	b := h.become
+	return types.BecomeFrom(ctx) && b.Password != "" && b.Method != "" && b.Method != "sudo"
+}
+
+// executeOnTTY executes the given command in a pseudo terminal,
+// and answers the password prompt of the escalation method,
+// which follows the mark line printed by the runtime right before each escalation.
+func (h *Host) executeOnTTY(ctx context.Context, out io.Writer, cmd string, args []string) error {
+	mark := fmt.Sprintf(`#courier-become-%s#`, strx.Hex(8))
+
+	t, err := h.shell(ctx,
+		types.WithShellPTY(),
+		types.WithShellTriggeredResponder(mark, becomePromptRegexp, h.become.Password))
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = t.Close() }()
+
+	// Pass the escalation to the runtime by environment variables except the password.
+	b := h.become
+
+	err = t.Execute(
+		"export",
+		shquot.POSIXShell([]string{"COURIER_BECOME_METHOD=" + b.Method}),
+		shquot.POSIXShell([]string{"COURIER_BECOME_USER=" + b.User}),
+		shquot.POSIXShell([]string{"COURIER_BECOME_TTY_MARK=" + mark}),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to prepare terminal: %w", err)
+	}
+
+	return t.doExecute(out, codec.EncodeExecInput(h.platform, cmd, args), nil)
+}
+
 type session struct {
 	*ssh.Session
 	context.Context
@@ -1,23 +1,26 @@
 package ssh
 
 import (
-	"bufio"
 	"context"
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 
+	"golang.org/x/crypto/ssh"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/seal-io/terraform-provider-courier/pkg/target/codec"
 	"github.com/seal-io/terraform-provider-courier/pkg/target/types"
 	"github.com/seal-io/terraform-provider-courier/utils/bytespool"
+	"github.com/seal-io/terraform-provider-courier/utils/iox"
 	"github.com/seal-io/terraform-provider-courier/utils/strx"
 )
 
 type Terminal struct {
-	io.WriteCloser
-	io.Reader
+	stdin  io.WriteCloser
+	input  io.Writer
+	output *codec.ShellReader
 
 	platform string
 	session  *session
@@ -26,16 +29,36 @@ type Terminal struct {
 
 func (h *Host) Shell(
 	ctx context.Context,
-	cmdArgs ...string,
+	opts ...types.ShellOption,
 ) (types.Terminal, error) {
+	return h.shell(ctx, opts...)
+}
+
+func (h *Host) shell(
+	ctx context.Context,
+	opts ...types.ShellOption,
+) (*Terminal, error) {
 	echo := fmt.Sprintf(`#%s#`, strx.Hex(8))
+	o := types.NewShellOptions(opts...)
 
 	s, err := h.getSessionWithContext(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	i, o, e, err := func() (stdin io.WriteCloser, stdout, stderr io.Reader, err error) {
+	i, out, e, err := func() (stdin io.WriteCloser, stdout, stderr io.Reader, err error) {
+		// Disable the echo to keep the input out of the output.
+		if o.PTY {
+			err = s.RequestPty("dumb", 40, 200, ssh.TerminalModes{
+				ssh.ECHO:          0,
+				ssh.TTY_OP_ISPEED: 14400,
+				ssh.TTY_OP_OSPEED: 14400,
+			})
+			if err != nil {
+				return
+			}
+		}
+
 		stdin, err = s.StdinPipe()
 		if err != nil {
 			return
@@ -56,9 +79,9 @@ func (h *Host) Shell(
 			args []string
 		)
 
-		if len(cmdArgs) > 0 {
-			cmd = cmdArgs[0]
-			args = cmdArgs[1:]
+		if len(o.Command) > 0 {
+			cmd = o.Command[0]
+			args = o.Command[1:]
 		} else {
 			cmd = "/bin/sh"
 		}
@@ -71,19 +94,41 @@ func (h *Host) Shell(
 		return nil, err
 	}
 
-	return &Terminal{
-		WriteCloser: i,
-		Reader:      io.MultiReader(o, e),
-		platform:    h.platform,
-		session:     s,
-		echo:        echo,
-	}, nil
+	input := iox.SingleWriter(i)
+
+	t := &Terminal{
+		stdin:    i,
+		input:    input,
+		output:   codec.NewShellReader(input, o, out, e),
+		platform: h.platform,
+		session:  s,
+		echo:     echo,
+	}
+
+	// Clear the prompts of the interactive shell.
+	if o.PTY && len(o.Command) == 0 {
+		if err = t.sync("PS1=''; PS2=''"); err != nil {
+			_ = t.Close()
+			return nil, fmt.Errorf("failed to prepare terminal: %w", err)
+		}
+	}
+
+	return t, nil
+}
+
+func (t *Terminal) Read(p []byte) (int, error) {
+	return t.output.Read(p)
+}
+
+func (t *Terminal) Write(p []byte) (int, error) {
+	return t.input.Write(p)
 }
 
 func (t *Terminal) Close() error {
 	defer func() { _ = t.session.Close() }()
 
-	_ = t.WriteCloser.Close()
+	_ = t.stdin.Close()
+	_ = t.output.Close()
 
 	return t.session.Wait()
 }
@@ -103,6 +148,27 @@ func (t *Terminal) ExecuteWithOutput(
 	return buf.Bytes(), err
 }
 
+// sync executes the given input and discards the output until the echo,
+// which tolerates the prompt before the echo,
+// the echo is quoted to not be taken as a comment.
+func (t *Terminal) sync(input string) error {
+	_, err := io.WriteString(t, fmt.Sprintf("%s; echo '%s'\n", input, t.echo))
+	if err != nil {
+		return err
+	}
+
+	for {
+		line, err := t.output.ReadLine()
+		if err != nil {
+			return err
+		}
+
+		if strings.HasSuffix(line, t.echo) {
+			return nil
+		}
+	}
+}
+
 func (t *Terminal) doExecute(
 	wr io.Writer,
 	cmd string,
@@ -121,22 +187,30 @@ func (t *Terminal) doExecute(
 	})
 
 	g.Go(func() error {
-		s := bufio.NewScanner(t)
-		for s.Scan() {
-			output := s.Text()
+		for i := 0; ; i++ {
+			output, err := t.output.ReadLine()
+			if err != nil {
+				return err
+			}
 
 			found, err := codec.DecodeShellOutput(&output, t.echo)
 			if found {
 				return err
 			}
 
+			// Separate the lines except the last one.
+			if i > 0 {
+				_, err = wr.Write([]byte{'\n'})
+				if err != nil {
+					return err
+				}
+			}
+
 			_, err = wr.Write(strx.ToBytes(&output))
 			if err != nil {
 				return err
 			}
 		}
-
-		return s.Err()
 	})
 
 	return g.Wait()
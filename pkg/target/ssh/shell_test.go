@@ -0,0 +1,196 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/seal-io/terraform-provider-courier/pkg/target/types"
+)
+
+func TestTerminal(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not found")
+	}
+
+	key, _ := generateKey(t)
+
+	cfg := &ssh.ServerConfig{
+		PasswordCallback: func(_ ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if string(password) != "good" {
+				return nil, errors.New("rejected")
+			}
+
+			return &ssh.Permissions{}, nil
+		},
+	}
+	cfg.AddHostKey(mustSigner(t, key))
+
+	addr := serveSSH(t, cfg, serveSession)
+
+	newHost := func(t *testing.T, become types.HostOptionBecome) *Host {
+		t.Helper()
+
+		h, err := New(types.HostOptions{
+			HostOption: types.HostOption{
+				Address: addr,
+				Authn: types.HostOptionAuthn{
+					Type:     "ssh",
+					User:     "root",
+					Password: "good",
+					Become:   become,
+				},
+				Insecure: true,
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t.Cleanup(func() { _ = h.Close() })
+
+		return h.(*Host)
+	}
+
+	ctx := context.TODO()
+
+	t.Run("execute", func(t *testing.T) {
+		term, err := newHost(t, types.HostOptionBecome{}).Shell(ctx)
+		if !assert.NoError(t, err, "should not return error") {
+			return
+		}
+
+		defer func() { _ = term.Close() }()
+
+		output, err := term.ExecuteWithOutput("echo", "hello")
+		if assert.NoError(t, err, "should not return error") {
+			assert.Equal(t, "hello", string(output))
+		}
+
+		err = term.Execute("false")
+		assert.EqualError(t, err, "exit code 1")
+	})
+
+	t.Run("interleave stderr", func(t *testing.T) {
+		term, err := newHost(t, types.HostOptionBecome{}).Shell(ctx)
+		if !assert.NoError(t, err, "should not return error") {
+			return
+		}
+
+		defer func() { _ = term.Close() }()
+
+		// Write more than the pipe buffer to stderr before stdout.
+		output, err := term.ExecuteWithOutput(
+			"head -c 131072 /dev/zero | tr '\\0' e >&2; echo >&2; echo out")
+		if assert.NoError(t, err, "should not return error") {
+			lines := strings.Split(string(output), "\n")
+			sort.Strings(lines)
+			assert.Equal(t, []string{strings.Repeat("e", 131072), "out"}, lines)
+		}
+	})
+
+	t.Run("respond prompt", func(t *testing.T) {
+		term, err := newHost(t, types.HostOptionBecome{}).Shell(ctx,
+			types.WithShellPTY(),
+			types.WithShellResponder(regexp.MustCompile(`password for \S+: $`), "secret"))
+		if !assert.NoError(t, err, "should not return error") {
+			return
+		}
+
+		defer func() { _ = term.Close() }()
+
+		output, err := term.ExecuteWithOutput(
+			`printf 'password for foo: ' >&2; read -r p; echo "got ${p}"`)
+		if assert.NoError(t, err, "should not return error") {
+			assert.Equal(t, "password for foo: \ngot secret", string(output))
+		}
+	})
+
+	t.Run("become on terminal", func(t *testing.T) {
+		h := newHost(t, types.HostOptionBecome{Method: "su", Password: "secret"})
+
+		output, err := h.ExecuteWithOutput(types.WithBecome(ctx),
+			"sh", "-c", `echo "${COURIER_BECOME_TTY_MARK}"; printf 'Password: '; `+
+				`read -r p; echo "${COURIER_BECOME_METHOD} ${p}"`)
+		if assert.NoError(t, err, "should not return error") {
+			assert.Equal(t, "Password: \nsu secret", string(output))
+		}
+
+		// Keep the password and the escalation out of the command without escalating.
+		output, err = h.ExecuteWithOutput(ctx,
+			"sh", "-c", `echo "[${COURIER_BECOME_METHOD:-}] [${COURIER_BECOME_TTY_MARK:-}]"`)
+		if assert.NoError(t, err, "should not return error") {
+			assert.Equal(t, "[] []\n", string(output))
+		}
+	})
+}
+
+// serveSession runs the command requested by the session in sh,
+// the pseudo terminal request is accepted but not allocated.
+func serveSession(nc ssh.NewChannel) {
+	if nc.ChannelType() != "session" {
+		_ = nc.Reject(ssh.UnknownChannelType, "not supported")
+		return
+	}
+
+	ch, reqs, err := nc.Accept()
+	if err != nil {
+		return
+	}
+
+	for req := range reqs {
+		switch req.Type {
+		default:
+			_ = req.Reply(false, nil)
+		case "pty-req":
+			_ = req.Reply(true, nil)
+		case "exec":
+			var p struct{ Command string }
+			if ssh.Unmarshal(req.Payload, &p) != nil {
+				_ = req.Reply(false, nil)
+				continue
+			}
+
+			_ = req.Reply(true, nil)
+
+			go func() {
+				defer func() { _ = ch.Close() }()
+
+				cmd := exec.Command("sh", "-c", p.Command)
+				cmd.Stdout = ch
+				cmd.Stderr = ch.Stderr()
+
+				stdin, err := cmd.StdinPipe()
+				if err != nil {
+					return
+				}
+
+				go func() {
+					_, _ = io.Copy(stdin, ch)
+					_ = stdin.Close()
+				}()
+
+				var status struct{ Status uint32 }
+
+				if err = cmd.Run(); err != nil {
+					status.Status = 1
+
+					var ee *exec.ExitError
+					if errors.As(err, &ee) {
+						status.Status = uint32(ee.ExitCode())
+					}
+				}
+
+				_, _ = ch.SendRequest("exit-status", false, ssh.Marshal(&status))
+			}()
+		}
+	}
+}
@@ -3,8 +3,11 @@ package ssh
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"io/fs"
+	"os"
+	"strings"
 
 	"github.com/pkg/sftp"
 
@@ -16,6 +19,7 @@ func (h *Host) UploadFile(
 	ctx context.Context,
 	from types.FileReader,
 	to string,
+	opts ...types.TransmitOption,
 ) (err error) {
 	if from == nil {
 		return errors.New("nil local file reader")
@@ -32,6 +36,13 @@ func (h *Host) UploadFile(
 
 	defer func() { _ = c.Close() }()
 
+	o := types.NewTransmitOptions(opts...)
+	if o.Mode != 0 {
+		// Recreate the file to avoid writing to the file with the previous permission,
+		// the following creating fails if the file cannot be removed and is not writable.
+		_ = c.Remove(to)
+	}
+
 	wr, err := c.Create(to)
 	if err != nil {
 		return err
@@ -43,17 +54,188 @@ func (h *Host) UploadFile(
 		}
 	}()
 
+	if o.Mode != 0 {
+		if err = wr.Chmod(o.Mode); err != nil {
+			return err
+		}
+	}
+
+	p := types.NewTransmitProgressor(types.SizeOf(from), opts...)
+
+	buf := bytespool.GetBytes()
+	defer func() { bytespool.Put(buf) }()
+
+	_, err = io.CopyBuffer(wr, p.Reader(from), buf)
+	if err != nil {
+		return err
+	}
+
+	p.Done()
+
+	return nil
+}
+
+func (h *Host) UploadFileResumable(
+	ctx context.Context,
+	from types.FileReadSeeker,
+	to string,
+	opts ...types.TransmitOption,
+) (err error) {
+	if from == nil {
+		return errors.New("nil local file reader")
+	}
+
+	if to == "" {
+		return errors.New("blank remote file path")
+	}
+
+	total, err := from.Seek(0, io.SeekEnd)
+	if err != nil {
+		return fmt.Errorf("failed to seek local file: %w", err)
+	}
+
+	sum, err := types.SumSHA256(from, -1)
+	if err != nil {
+		return err
+	}
+
+	c, err := h.getFileTransportWithContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = c.Close() }()
+
+	partial := to + types.PartialSuffix
+
+	p := types.NewTransmitProgressor(total, opts...)
+
+	// Retry from the partial file left by the failed attempt.
+	for i := 1; ; i++ {
+		offset, err := h.resumeOffset(ctx, c, from, partial, total)
+		if err != nil {
+			return err
+		}
+
+		p.Resume(offset)
+
+		if offset == total {
+			break
+		}
+
+		err = h.uploadFrom(c, from, partial, offset, p)
+		if err == nil {
+			break
+		}
+
+		if i >= types.ResumableAttempts || ctx.Err() != nil {
+			return err
+		}
+	}
+
+	p.Done()
+
+	// Verify.
+	rs, err := h.sumSHA256(ctx, partial)
+	if err != nil {
+		return err
+	}
+
+	if rs != sum {
+		_ = c.Remove(partial)
+		return fmt.Errorf("mismatched remote checksum sha256:%s, expected sha256:%s", rs, sum)
+	}
+
+	return c.PosixRename(partial, to)
+}
+
+// resumeOffset returns the size of the given partial file if it is the prefix of the local file,
+// otherwise, returns zero to upload from scratch.
+func (h *Host) resumeOffset(
+	ctx context.Context,
+	c *fileTransport,
+	from types.FileReadSeeker,
+	partial string,
+	total int64,
+) (int64, error) {
+	i, err := c.Stat(partial)
+	if err != nil || !i.Mode().IsRegular() || i.Size() > total {
+		return 0, nil
+	}
+
+	ls, err := types.SumSHA256(from, i.Size())
+	if err != nil {
+		return 0, err
+	}
+
+	if rs, err := h.sumSHA256(ctx, partial); err != nil || rs != ls {
+		return 0, nil
+	}
+
+	return i.Size(), nil
+}
+
+// uploadFrom uploads the given file from the given offset to the given remote path,
+// truncates the remote file if the offset is zero.
+func (h *Host) uploadFrom(
+	c *fileTransport,
+	from types.FileReadSeeker,
+	to string,
+	offset int64,
+	p *types.TransmitProgressor,
+) (err error) {
+	if _, err = from.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek local file: %w", err)
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE
+	if offset == 0 {
+		flags |= os.O_TRUNC
+	}
+
+	wr, err := c.OpenFile(to, flags)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if cerr := wr.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	if _, err = wr.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
 	buf := bytespool.GetBytes()
 	defer func() { bytespool.Put(buf) }()
 
-	_, err = io.CopyBuffer(wr, from, buf)
+	_, err = io.CopyBuffer(wr, p.Reader(from), buf)
+
 	return err
 }
 
+// sumSHA256 returns the hex-encoded SHA-256 checksum of the given remote file.
+func (h *Host) sumSHA256(ctx context.Context, path string) (string, error) {
+	output, err := h.ExecuteWithOutput(ctx, "sha256sum", path)
+	if err != nil {
+		return "", fmt.Errorf("failed to sum remote file: %w: %s", err, output)
+	}
+
+	fs := strings.Fields(string(output))
+	if len(fs) == 0 {
+		return "", errors.New("failed to sum remote file: blank output")
+	}
+
+	return fs[0], nil
+}
+
 func (h *Host) UploadDirectory(
 	ctx context.Context,
 	from types.DirectoryReader,
 	to string,
+	opts ...types.TransmitOption,
 ) error {
 	if from == nil {
 		return errors.New("nil local directory reader")
@@ -75,7 +257,10 @@ func (h *Host) UploadDirectory(
 		return err
 	}
 
-	return fs.WalkDir(
+	o := types.NewTransmitOptions(opts...)
+	tp := types.NewTransmitProgressor(types.SizeOfDirectory(from), opts...)
+
+	err = fs.WalkDir(
 		from,
 		".",
 		func(p string, d fs.DirEntry, ierr error) (err error) {
@@ -94,6 +279,11 @@ func (h *Host) UploadDirectory(
 
 			defer func() { _ = rd.Close() }()
 
+			if o.Mode != 0 {
+				// Recreate the file to avoid writing to the file with the previous permission.
+				_ = c.Remove(to + "/" + p)
+			}
+
 			wr, err := c.Create(to + "/" + p)
 			if err != nil {
 				return err
@@ -105,13 +295,26 @@ func (h *Host) UploadDirectory(
 				}
 			}()
 
+			if o.Mode != 0 {
+				if err = wr.Chmod(o.Mode); err != nil {
+					return err
+				}
+			}
+
 			buf := bytespool.GetBytes()
 			defer func() { bytespool.Put(buf) }()
 
-			_, err = io.CopyBuffer(wr, rd, buf)
+			_, err = io.CopyBuffer(wr, tp.Reader(rd), buf)
 			return err
 		},
 	)
+	if err != nil {
+		return err
+	}
+
+	tp.Done()
+
+	return nil
 }
 
 type file struct {
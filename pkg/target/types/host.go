@@ -2,6 +2,7 @@ package types
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"net"
 	"net/url"
@@ -21,6 +22,18 @@ type (
 		Execute(ctx context.Context, cmd string, args ...string) error
 		// ExecuteWithOutput executes the given command on the host and returns the output.
 		ExecuteWithOutput(ctx context.Context, cmd string, args ...string) ([]byte, error)
+		// ExecuteWithStream executes the given command on the host,
+		// and writes the combined output to the given writer as it arrives.
+		ExecuteWithStream(ctx context.Context, out io.Writer, cmd string, args ...string) error
+		// Dial connects to the given address from the host,
+		// the loopback address refers to the host itself.
+		Dial(ctx context.Context, network, address string) (net.Conn, error)
+	}
+
+	// KeepAliver is implemented by the host which holds a long-lived connection.
+	KeepAliver interface {
+		// KeepAlive returns error if the connection is broken.
+		KeepAlive() error
 	}
 
 	HostStatus struct {
@@ -31,17 +44,52 @@ type (
 	}
 )
 
+// ExitError is returned if the command exits with a non-zero code.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// ExitStatus returns the exit code,
+// which is the same as the ssh.ExitError.
+func (e *ExitError) ExitStatus() int {
+	return e.Code
+}
+
+type becomeContextKey struct{}
+
+// WithBecome returns a context which escalates the commands executed with it,
+// i.e. passes the Become options of the host to the command.
+func WithBecome(ctx context.Context) context.Context {
+	return context.WithValue(ctx, becomeContextKey{}, true)
+}
+
+// BecomeFrom returns true if the commands executed with the given context escalate.
+func BecomeFrom(ctx context.Context) bool {
+	b, _ := ctx.Value(becomeContextKey{}).(bool)
+	return b
+}
+
 type (
 	HostOptions struct {
 		HostOption
 
 		Proxies []HostOption
+		// Forward dials the host instead of dialing via the Proxies if not nil,
+		// which is shared and not closed by the host.
+		Forward DialCloser
 	}
 
 	HostOption struct {
 		Address  string
 		Authn    HostOptionAuthn
+		HostKey  HostOptionHostKey
 		Insecure bool
+		// CACert is the PEM encoded CA certificates to verify the HTTPS endpoint of WinRM.
+		CACert string
 	}
 
 	HostOptionAuthn struct {
@@ -49,6 +97,58 @@ type (
 		User   string
 		Secret string
 		Agent  bool
+		// Passphrase decrypts the private key given by Secret.
+		Passphrase string
+		// Certificate is the SSH user certificate signed for the private key,
+		// in form of authorized_keys,
+		// or the PEM encoded client certificate of WinRM signed for the private key.
+		Certificate string
+		// Password is tried after the agent and private key.
+		Password string
+		// Become escalates the privilege of the runtime on the host.
+		Become HostOptionBecome
+		// Kerberos authenticates the WinRM user within the realm.
+		Kerberos HostOptionKerberos
+	}
+
+	HostOptionBecome struct {
+		// Method is the escalation method, e.g. sudo, su, doas or pbrun,
+		// guesses between root and sudo if blank.
+		Method string
+		// User is the user to become, defaults to root.
+		User string
+		// Password is sent to the runtime over stdin,
+		// only for the commands executed with the context returned by WithBecome.
+		Password string
+	}
+
+	HostOptionKerberos struct {
+		// Realm is the realm of the user,
+		// enables the Kerberos authentication if not blank.
+		Realm string
+		// Config is the path of the krb5.conf,
+		// defaults to $KRB5_CONFIG or /etc/krb5.conf.
+		Config string
+		// Keytab is the path of the keytab to authenticate instead of the password.
+		Keytab string
+		// CCache is the path of the credential cache to authenticate instead of the password,
+		// e.g. the one obtained by kinit.
+		CCache string
+		// SPN is the service principal name of the host, defaults to HTTP/<host>.
+		SPN string
+	}
+
+	HostOptionHostKey struct {
+		// KnownHosts is the path of the known_hosts file,
+		// defaults to ~/.ssh/known_hosts if exists.
+		KnownHosts string
+		// Fingerprints pins the host key,
+		// in form of SHA256:base64 or MD5:hex.
+		Fingerprints []string
+		// TrustOnFirstUse accepts the host key which is not known or pinned,
+		// and reports its fingerprint via Trusted if not nil.
+		TrustOnFirstUse bool
+		Trusted         func(fingerprint string)
 	}
 )
 
@@ -70,8 +170,13 @@ func (o HostOption) ParseAddress() (parsed HostAddressParsed, err error) {
 		}
 
 		parsed.Scheme = u.Scheme
-		parsed.Host = u.Host
-		parsed.Port, err = strconv.Atoi(u.Port())
+		// Keep the port and the brackets of IPv6 out of the host,
+		// which are added back by HostPort.
+		parsed.Host = u.Hostname()
+
+		if p := u.Port(); p != "" {
+			parsed.Port, err = strconv.Atoi(p)
+		}
 
 		return parsed, err
 	}
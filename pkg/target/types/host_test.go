@@ -0,0 +1,88 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostOption_ParseAddress(t *testing.T) {
+	testCases := []struct {
+		name     string
+		address  string
+		expected HostAddressParsed
+	}{
+		{
+			name:     "host",
+			address:  "10.0.0.1",
+			expected: HostAddressParsed{Host: "10.0.0.1"},
+		},
+		{
+			name:     "host and port",
+			address:  "10.0.0.1:2222",
+			expected: HostAddressParsed{Host: "10.0.0.1", Port: 2222},
+		},
+		{
+			name:     "scheme and host",
+			address:  "https://win.example.com",
+			expected: HostAddressParsed{Scheme: "https", Host: "win.example.com"},
+		},
+		{
+			name:     "scheme, host and port",
+			address:  "https://win.example.com:5986",
+			expected: HostAddressParsed{Scheme: "https", Host: "win.example.com", Port: 5986},
+		},
+		{
+			name:     "scheme and ipv6 host",
+			address:  "https://[fe80::1]",
+			expected: HostAddressParsed{Scheme: "https", Host: "fe80::1"},
+		},
+		{
+			name:     "scheme, ipv6 host and port",
+			address:  "https://[fe80::1]:5986",
+			expected: HostAddressParsed{Scheme: "https", Host: "fe80::1", Port: 5986},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := HostOption{Address: tc.address}.ParseAddress()
+			if assert.NoError(t, err, "should not return error") {
+				assert.Equal(t, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestHostAddressParsed_HostPort(t *testing.T) {
+	testCases := []struct {
+		name     string
+		address  string
+		expected string
+	}{
+		{
+			name:     "default port",
+			address:  "https://win.example.com",
+			expected: "win.example.com:5986",
+		},
+		{
+			name:     "port",
+			address:  "https://win.example.com:443",
+			expected: "win.example.com:443",
+		},
+		{
+			name:     "ipv6 host and port",
+			address:  "https://[fe80::1]:443",
+			expected: "[fe80::1]:443",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			parsed, err := HostOption{Address: tc.address}.ParseAddress()
+			if assert.NoError(t, err, "should not return error") {
+				assert.Equal(t, tc.expected, parsed.HostPort(5986))
+			}
+		})
+	}
+}
@@ -3,12 +3,13 @@ package types
 import (
 	"context"
 	"io"
+	"regexp"
 )
 
 type (
 	Sheller interface {
 		// Shell returns a terminal to execute multiple commands on the host.
-		Shell(ctx context.Context, cmdArgs ...string) (Terminal, error)
+		Shell(ctx context.Context, opts ...ShellOption) (Terminal, error)
 	}
 
 	Terminal interface {
@@ -20,3 +21,82 @@ type (
 		ExecuteWithOutput(cmd string, args ...string) ([]byte, error)
 	}
 )
+
+type (
+	ShellOptions struct {
+		// Command starts the terminal, defaults to the shell of the host.
+		Command []string
+		// PTY allocates a pseudo terminal without echo,
+		// which is required by the programs prompting on the terminal, e.g. su,
+		// it is ignored by the host without pseudo terminal.
+		PTY bool
+		// Responders answer the prompts in the output.
+		Responders []ShellResponder
+	}
+
+	ShellOption func(*ShellOptions)
+
+	// ShellResponder answers the prompt matched in the output,
+	// e.g. the password prompt of sudo or the host key confirmation of ssh.
+	ShellResponder struct {
+		// Prompt matches the partial line waiting for the input,
+		// e.g. `password for \S+: $`.
+		Prompt *regexp.Regexp
+		Answer string
+		// Trigger arms the responder by the output line equal to it,
+		// the armed responder answers only once and disarms on the next line,
+		// answers every matched prompt if blank.
+		Trigger string
+	}
+)
+
+// WithShellCommand starts the terminal with the given command.
+func WithShellCommand(cmdArgs ...string) ShellOption {
+	return func(o *ShellOptions) {
+		o.Command = cmdArgs
+	}
+}
+
+// WithShellPTY allocates a pseudo terminal for the terminal.
+func WithShellPTY() ShellOption {
+	return func(o *ShellOptions) {
+		o.PTY = true
+	}
+}
+
+// WithShellResponder answers the given prompt with the given answer,
+// the answer is written with a line feed.
+func WithShellResponder(prompt *regexp.Regexp, answer string) ShellOption {
+	return func(o *ShellOptions) {
+		o.Responders = append(o.Responders, ShellResponder{
+			Prompt: prompt,
+			Answer: answer,
+		})
+	}
+}
+
+// WithShellTriggeredResponder answers the given prompt with the given answer,
+// only if the prompt follows the given trigger line,
+// the trigger line is not delivered to the output.
+func WithShellTriggeredResponder(trigger string, prompt *regexp.Regexp, answer string) ShellOption {
+	return func(o *ShellOptions) {
+		o.Responders = append(o.Responders, ShellResponder{
+			Prompt:  prompt,
+			Answer:  answer,
+			Trigger: trigger,
+		})
+	}
+}
+
+// NewShellOptions returns the ShellOptions applied the given options.
+func NewShellOptions(opts ...ShellOption) ShellOptions {
+	var o ShellOptions
+
+	for i := range opts {
+		if opts[i] != nil {
+			opts[i](&o)
+		}
+	}
+
+	return o
+}
@@ -2,12 +2,17 @@ package types
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"io"
 	"io/fs"
+	"time"
 )
 
 type (
 	FileReader          = io.Reader
+	FileReadSeeker      = io.ReadSeeker
 	FileReadCloser      = io.ReadCloser
 	DirectoryReader     = fs.FS
 	DirectoryReadCloser = interface {
@@ -17,12 +22,241 @@ type (
 
 	Transmitter interface {
 		// UploadFile uploads the given file to the host.
-		UploadFile(ctx context.Context, from FileReader, to string) error
+		UploadFile(ctx context.Context, from FileReader, to string, opts ...TransmitOption) error
+		// UploadFileResumable uploads the given file to the host via a temporary file,
+		// resumes from the temporary file left by the previous failed uploading if its content matches,
+		// verifies the SHA-256 checksum computed by the host and then renames the file atomically.
+		UploadFileResumable(ctx context.Context, from FileReadSeeker, to string, opts ...TransmitOption) error
 		// UploadDirectory uploads the given directory to the host.
-		UploadDirectory(ctx context.Context, from DirectoryReader, to string) error
+		UploadDirectory(ctx context.Context, from DirectoryReader, to string, opts ...TransmitOption) error
 		// DownloadFile downloads the given file from the host.
 		DownloadFile(ctx context.Context, from string) (FileReadCloser, error)
 		// DownloadDirectory downloads the given directory from the host.
 		DownloadDirectory(ctx context.Context, from string) (DirectoryReadCloser, error)
 	}
 )
+
+type (
+	TransmitOptions struct {
+		// Progress is called at every ProgressInterval during the uploading,
+		// and once more at the end.
+		Progress func(TransmitProgress)
+		// ProgressInterval is the interval of reporting the progress,
+		// default is 5 seconds.
+		ProgressInterval time.Duration
+		// Mode is the permission of the uploaded file or each file of the uploaded directory,
+		// the file is recreated with the permission before writing any content,
+		// default is the host's default, it is ignored by the host without POSIX permission.
+		Mode fs.FileMode
+	}
+
+	TransmitOption func(*TransmitOptions)
+
+	TransmitProgress struct {
+		// Sent is the bytes sent so far,
+		// including the bytes resumed from the previous uploading.
+		Sent int64
+		// Total is the total bytes to send, -1 if unknown.
+		Total int64
+		// Rate is the bytes sent per second.
+		Rate float64
+	}
+)
+
+// WithTransmitProgress reports the progress of the uploading to the given function.
+func WithTransmitProgress(fn func(TransmitProgress)) TransmitOption {
+	return func(o *TransmitOptions) {
+		o.Progress = fn
+	}
+}
+
+// WithTransmitProgressInterval changes the interval of reporting the progress.
+func WithTransmitProgressInterval(d time.Duration) TransmitOption {
+	return func(o *TransmitOptions) {
+		o.ProgressInterval = d
+	}
+}
+
+// WithTransmitMode changes the permission of the uploaded file.
+func WithTransmitMode(mode fs.FileMode) TransmitOption {
+	return func(o *TransmitOptions) {
+		o.Mode = mode
+	}
+}
+
+// NewTransmitOptions returns the TransmitOptions applied the given options.
+func NewTransmitOptions(opts ...TransmitOption) TransmitOptions {
+	o := TransmitOptions{
+		ProgressInterval: 5 * time.Second,
+	}
+
+	for i := range opts {
+		if opts[i] != nil {
+			opts[i](&o)
+		}
+	}
+
+	return o
+}
+
+// TransmitProgressor tracks the progress of the uploading,
+// it is a no-op if there is no progress function.
+type TransmitProgressor struct {
+	opts    TransmitOptions
+	total   int64
+	base    int64
+	sent    int64
+	start   time.Time
+	last    time.Time
+	nowFunc func() time.Time
+}
+
+// NewTransmitProgressor returns a TransmitProgressor with the given total bytes,
+// which is -1 if unknown.
+func NewTransmitProgressor(total int64, opts ...TransmitOption) *TransmitProgressor {
+	p := &TransmitProgressor{
+		opts:    NewTransmitOptions(opts...),
+		total:   total,
+		nowFunc: time.Now,
+	}
+
+	p.start = p.nowFunc()
+	p.last = p.start
+
+	return p
+}
+
+// Resume counts the given bytes as sent without contributing to the rate,
+// and forgets the bytes sent by the previous failed attempt.
+func (p *TransmitProgressor) Resume(n int64) {
+	p.base = n
+	p.sent = 0
+}
+
+// Add counts the given bytes as sent and reports the progress if the interval elapsed.
+func (p *TransmitProgressor) Add(n int64) {
+	p.sent += n
+
+	if p.opts.Progress == nil {
+		return
+	}
+
+	if now := p.nowFunc(); now.Sub(p.last) >= p.opts.ProgressInterval {
+		p.last = now
+		p.opts.Progress(p.progress(now))
+	}
+}
+
+// Done reports the final progress.
+func (p *TransmitProgressor) Done() {
+	if p.opts.Progress == nil {
+		return
+	}
+
+	p.opts.Progress(p.progress(p.nowFunc()))
+}
+
+// Reader returns a reader which counts the read bytes as sent.
+func (p *TransmitProgressor) Reader(r io.Reader) io.Reader {
+	if p.opts.Progress == nil {
+		return r
+	}
+
+	return progressReader{r: r, p: p}
+}
+
+func (p *TransmitProgressor) progress(now time.Time) TransmitProgress {
+	tp := TransmitProgress{
+		Sent:  p.base + p.sent,
+		Total: p.total,
+	}
+
+	if d := now.Sub(p.start).Seconds(); d > 0 {
+		tp.Rate = float64(p.sent) / d
+	}
+
+	return tp
+}
+
+type progressReader struct {
+	r io.Reader
+	p *TransmitProgressor
+}
+
+func (r progressReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	r.p.Add(int64(n))
+
+	return n, err
+}
+
+// SizeOf returns the size of the given file,
+// or -1 if the size cannot be determined without reading.
+func SizeOf(r FileReader) int64 {
+	switch v := r.(type) {
+	case interface{ Stat() (fs.FileInfo, error) }:
+		if i, err := v.Stat(); err == nil && i.Mode().IsRegular() {
+			return i.Size()
+		}
+	case interface{ Len() int }:
+		return int64(v.Len())
+	}
+
+	return -1
+}
+
+// SizeOfDirectory returns the total size of the files in the given directory,
+// or -1 if the size cannot be determined.
+func SizeOfDirectory(r DirectoryReader) int64 {
+	var total int64
+
+	err := fs.WalkDir(r, ".", func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		i, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		total += i.Size()
+
+		return nil
+	})
+	if err != nil {
+		return -1
+	}
+
+	return total
+}
+
+// PartialSuffix is the suffix of the temporary file of the resumable uploading.
+const PartialSuffix = ".partial"
+
+// ResumableAttempts is the maximum number of attempts of the resumable uploading within a run,
+// each attempt resumes from the temporary file left by the previous one.
+const ResumableAttempts = 3
+
+// SumSHA256 returns the hex-encoded SHA-256 checksum of the first n bytes of the given file,
+// or of the whole file if n is negative.
+func SumSHA256(r FileReadSeeker, n int64) (string, error) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("failed to seek file: %w", err)
+	}
+
+	h := sha256.New()
+
+	var err error
+	if n < 0 {
+		_, err = io.Copy(h, r)
+	} else {
+		_, err = io.CopyN(h, r, n)
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("failed to sum file: %w", err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
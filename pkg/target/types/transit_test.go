@@ -0,0 +1,98 @@
+package types
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSumSHA256(t *testing.T) {
+	rd := strings.NewReader("hello world")
+
+	testCases := []struct {
+		name     string
+		n        int64
+		expected string
+	}{
+		{
+			name:     "whole",
+			n:        -1,
+			expected: "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9",
+		},
+		{
+			name:     "prefix",
+			n:        5,
+			expected: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+		},
+		{
+			name:     "empty",
+			n:        0,
+			expected: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Seek to the end to ensure the sum starts from the beginning.
+			_, _ = rd.Seek(0, io.SeekEnd)
+
+			actual, err := SumSHA256(rd, tc.n)
+			if assert.NoError(t, err, "should not return error") {
+				assert.Equal(t, tc.expected, actual)
+			}
+		})
+	}
+
+	_, err := SumSHA256(rd, 100)
+	assert.Error(t, err, "should return error when the file is shorter")
+}
+
+func TestTransmitProgressor(t *testing.T) {
+	var (
+		now      = time.Unix(0, 0)
+		reported []TransmitProgress
+	)
+
+	p := NewTransmitProgressor(100,
+		WithTransmitProgress(func(tp TransmitProgress) {
+			reported = append(reported, tp)
+		}),
+		WithTransmitProgressInterval(time.Second))
+	p.nowFunc = func() time.Time { return now }
+	p.start, p.last = now, now
+	p.Resume(20)
+
+	// Not reported within the interval.
+	now = now.Add(500 * time.Millisecond)
+	p.Add(10)
+	assert.Len(t, reported, 0)
+
+	// Reported after the interval.
+	now = now.Add(500 * time.Millisecond)
+	p.Add(30)
+
+	if assert.Len(t, reported, 1) {
+		assert.Equal(t, TransmitProgress{Sent: 60, Total: 100, Rate: 40}, reported[0])
+	}
+
+	// Reported at the end.
+	now = now.Add(time.Second)
+	_, err := io.Copy(io.Discard, p.Reader(strings.NewReader(strings.Repeat("x", 40))))
+	assert.NoError(t, err, "should not return error")
+	p.Done()
+
+	if assert.Len(t, reported, 3) {
+		assert.Equal(t, TransmitProgress{Sent: 100, Total: 100, Rate: 40}, reported[2])
+	}
+
+	// Forgets the bytes sent by the failed attempt when resuming.
+	p.Resume(50)
+	p.Done()
+
+	if assert.Len(t, reported, 4) {
+		assert.Equal(t, TransmitProgress{Sent: 50, Total: 100, Rate: 0}, reported[3])
+	}
+}
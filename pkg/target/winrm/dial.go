@@ -1,6 +1,7 @@
 package winrm
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
@@ -18,18 +19,47 @@ func Dial(
 		return nil, fmt.Errorf("failed to parse proxy address: %w", err)
 	}
 
+	https := ap.Scheme == "https"
+
 	if ap.Port == 0 {
 		ap.Port = 5985
+		if https {
+			ap.Port = 5986
+		}
+	}
+
+	var caCert, cert, key []byte
+
+	if dialHost.CACert != "" {
+		caCert = []byte(dialHost.CACert)
+	}
+
+	au := dialHost.Authn
+
+	// Authenticate with the client certificate,
+	// which is mapped to the local user by the host.
+	if au.Certificate != "" {
+		if !https {
+			return nil, errors.New("certificate authentication requires https")
+		}
+
+		cert, key = []byte(au.Certificate), []byte(au.Secret)
+	}
+
+	// Authenticate with Kerberos over https,
+	// as the messages are not sealed by the transporter.
+	if au.Kerberos.Realm != "" && !https {
+		return nil, errors.New("kerberos authentication requires https")
 	}
 
 	ep := winrm.NewEndpoint(
 		ap.Host,
 		ap.Port,
-		ap.Scheme == "https",
+		https,
 		dialHost.Insecure,
-		nil,
-		nil,
-		nil,
+		caCert,
+		cert,
+		key,
 		15*time.Second,
 	)
 	ps := winrm.NewParameters("PT60S", "en-US", 153600)
@@ -38,9 +68,18 @@ func Dial(
 		ps.Dial = forward.Dial
 	}
 
-	if dialHost.Authn.Agent {
+	switch {
+	case au.Kerberos.Realm != "":
+		ps.TransportDecorator = func() winrm.Transporter {
+			return newKerberosTransporter(ps.Dial, au)
+		}
+	case au.Certificate != "":
+		ps.TransportDecorator = func() winrm.Transporter {
+			return winrm.NewClientAuthRequestWithDial(ps.Dial)
+		}
+	case au.Agent:
 		ps.TransportDecorator = func() winrm.Transporter {
-			return &winrm.ClientNTLM{}
+			return winrm.NewClientNTLMWithDial(ps.Dial)
 		}
 	}
 
@@ -0,0 +1,45 @@
+package winrm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seal-io/terraform-provider-courier/pkg/target/types"
+)
+
+func TestDial_RequireHTTPS(t *testing.T) {
+	testCases := []struct {
+		name    string
+		address string
+		authn   types.HostOptionAuthn
+	}{
+		{
+			name:    "kerberos over http",
+			address: "http://win.example.com",
+			authn: types.HostOptionAuthn{
+				Kerberos: types.HostOptionKerberos{Realm: "EXAMPLE.COM"},
+			},
+		},
+		{
+			name:    "certificate over http",
+			address: "win.example.com",
+			authn: types.HostOptionAuthn{
+				Certificate: "-",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.authn.Type = "winrm"
+			tc.authn.User = "administrator"
+
+			_, err := Dial(nil, types.HostOption{
+				Address: tc.address,
+				Authn:   tc.authn,
+			})
+			assert.ErrorContains(t, err, "requires https")
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package winrm
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/xml"
@@ -33,9 +34,9 @@ func (ft *fileTransport) Create(path string) (*writableFile, error) {
 	}
 
 	path = toWindowsPath(path)
-	command := winrm.Powershell(
-		fmt.Sprintf("New-Item -Force -ItemType File -Path %s", path),
-	)
+	command := winrm.Powershell(fmt.Sprintf(`
+		$path = "%s"
+		New-Item -Force -ItemType File -Path ${path} | Out-Null`, path))
 
 	err := execute(ft.ctx, ft.shell, io.Discard, command)
 	if err != nil {
@@ -49,6 +50,98 @@ func (ft *fileTransport) Create(path string) (*writableFile, error) {
 	}, nil
 }
 
+// Append returns a file to append the content to the end of the given path,
+// the content is staged on the host and appended at closing.
+func (ft *fileTransport) Append(path string) (*appendableFile, error) {
+	if path == "" {
+		return nil, errors.New("blank path")
+	}
+
+	path = toWindowsPath(path)
+	command := winrm.Powershell(fmt.Sprintf(`
+		$path = "%s"
+		if (-not (Test-Path ${path} -Type Leaf)) {
+			New-Item -Force -ItemType File -Path ${path} | Out-Null
+		}
+		New-Item -Force -ItemType File -Path ${path}.b64 | Out-Null`, path))
+
+	err := execute(ft.ctx, ft.shell, io.Discard, command)
+	if err != nil {
+		return nil, fmt.Errorf("failed to append file %q: %w", path, err)
+	}
+
+	return &appendableFile{
+		writableFile: writableFile{
+			ctx:   ft.ctx,
+			shell: ft.shell,
+			path:  path + ".b64",
+		},
+		target: path,
+	}, nil
+}
+
+// SumSHA256 returns the hex-encoded SHA-256 checksum of the given path.
+func (ft *fileTransport) SumSHA256(path string) (string, error) {
+	if path == "" {
+		return "", errors.New("blank path")
+	}
+
+	path = toWindowsPath(path)
+	command := winrm.Powershell(fmt.Sprintf(`
+		$path = "%s"
+		(Get-FileHash -Algorithm SHA256 -Path ${path}).Hash`, path))
+
+	buf := bytespool.GetBuffer()
+	defer func() { bytespool.Put(buf) }()
+
+	err := execute(ft.ctx, ft.shell, buf, command)
+	if err != nil {
+		return "", fmt.Errorf("failed to sum file %q: %w", path, err)
+	}
+
+	return strings.ToLower(strings.TrimSpace(buf.String())), nil
+}
+
+// Rename renames the given path to the given new path,
+// replaces the new path if it exists.
+func (ft *fileTransport) Rename(path, newPath string) error {
+	if path == "" || newPath == "" {
+		return errors.New("blank path")
+	}
+
+	path, newPath = toWindowsPath(path), toWindowsPath(newPath)
+	command := winrm.Powershell(fmt.Sprintf(`
+		$path = "%s"
+		$newPath = "%s"
+		Move-Item -Force -Path ${path} -Destination ${newPath}`, path, newPath))
+
+	err := execute(ft.ctx, ft.shell, io.Discard, command)
+	if err != nil {
+		return fmt.Errorf("failed to rename file %q: %w", path, err)
+	}
+
+	return nil
+}
+
+// Remove removes the given path.
+func (ft *fileTransport) Remove(path string) error {
+	if path == "" {
+		return errors.New("blank path")
+	}
+
+	path = toWindowsPath(path)
+	command := winrm.Powershell(fmt.Sprintf(`
+		$path = "%s"
+		Remove-Item -Force -ErrorAction SilentlyContinue -Path ${path}`, path))
+
+	err := execute(ft.ctx, ft.shell, io.Discard, command)
+	if err != nil {
+		return fmt.Errorf("failed to remove file %q: %w", path, err)
+	}
+
+	return nil
+}
+
 func (ft *fileTransport) Lstat(path string) (fs.FileInfo, error) {
 	if path == "" {
 		return nil, errors.New("blank path")
@@ -204,10 +297,55 @@ func (f *writableFile) Write(p []byte) (int, error) {
 	return len(p), nil
 }
 
+type appendableFile struct {
+	writableFile
+
+	target string
+}
+
+// Close appends the staged content to the target,
+// it doesn't close the shell which is owned by the fileTransport.
+func (f *appendableFile) Close() error {
+	if f.ctx.Err() != nil {
+		return f.ctx.Err()
+	}
+
+	// NB(thxCode): Append the staged content even if the writing failed,
+	// so that the next uploading can resume from the complete lines.
+	command := winrm.Powershell(fmt.Sprintf(`
+		$path = "%s"
+		$rd = [System.IO.File]::OpenText("${path}.b64")
+		$wr = [System.IO.File]::Open(${path}, [System.IO.FileMode]::Append)
+		try {
+			for(;;) {
+				$bs64 = $rd.ReadLine()
+				if (${bs64} -eq $null) { break }
+				try {
+					$bs = [System.Convert]::FromBase64String(${bs64})
+				} catch {
+					break
+				}
+				$wr.Write(${bs}, 0, ${bs}.Length)
+			}
+		} finally {
+			$rd.Close()
+			$wr.Close()
+			Remove-Item -Force -Path "${path}.b64"
+		}`, f.target))
+
+	err := execute(f.ctx, f.shell, io.Discard, command)
+	if err == nil {
+		err = f.err
+	}
+
+	return err
+}
+
 type readonlyFile struct {
 	ctx   context.Context
 	shell *winrm.Shell
 	path  string
+	rd    *bytes.Reader
 }
 
 func (f *readonlyFile) Close() error {
@@ -219,8 +357,31 @@ func (f *readonlyFile) Name() string {
 }
 
 func (f *readonlyFile) Read(p []byte) (int, error) {
-	// TODO(thxCode): implement this.
-	return 0, io.EOF
+	if f.rd == nil {
+		// NB(thxCode): Fetch the whole content in base64 at the first reading,
+		// which is suitable for small files.
+		command := winrm.Powershell(fmt.Sprintf(
+			"[System.Convert]::ToBase64String([System.IO.File]::ReadAllBytes(%s))",
+			f.path))
+
+		buf := bytespool.GetBuffer()
+		defer func() { bytespool.Put(buf) }()
+
+		err := execute(f.ctx, f.shell, buf, command)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read file %q: %w", f.path, err)
+		}
+
+		bs, err := base64.StdEncoding.DecodeString(
+			strings.TrimSpace(buf.String()))
+		if err != nil {
+			return 0, fmt.Errorf("failed to decode file %q: %w", f.path, err)
+		}
+
+		f.rd = bytes.NewReader(bs)
+	}
+
+	return f.rd.Read(p)
 }
 
 func (f *readonlyFile) Stat() (fs.FileInfo, error) {
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"strings"
 
 	"github.com/masterzen/winrm"
@@ -14,11 +15,14 @@ import (
 	"github.com/seal-io/terraform-provider-courier/pkg/target/ssh"
 	"github.com/seal-io/terraform-provider-courier/pkg/target/types"
 	"github.com/seal-io/terraform-provider-courier/utils/bytespool"
+	"github.com/seal-io/terraform-provider-courier/utils/iox"
 	"github.com/seal-io/terraform-provider-courier/utils/strx"
 )
 
 type Host struct {
-	client *winrm.Client
+	client  *winrm.Client
+	forward types.DialCloser
+	host    string
 }
 
 func New(opts types.HostOptions) (types.Host, error) {
@@ -30,13 +34,23 @@ func New(opts types.HostOptions) (types.Host, error) {
 		return nil, errors.New("no address specified")
 	}
 
-	proxies, err := proxyWith(types.DialClosers{}, opts.Proxies)
-	if err != nil {
-		return nil, fmt.Errorf(
-			"failed to dail %s via proxies: %w",
-			opts.Address,
-			err,
-		)
+	var (
+		proxies types.DialCloser
+		err     error
+	)
+
+	if opts.Forward != nil {
+		// Share the forward without closing.
+		proxies = types.DialCloserFunc(opts.Forward.Dial)
+	} else {
+		proxies, err = proxyWith(types.DialClosers{}, opts.Proxies)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"failed to dail %s via proxies: %w",
+				opts.Address,
+				err,
+			)
+		}
 	}
 
 	c, err := Dial(proxies, opts.HostOption)
@@ -44,8 +58,15 @@ func New(opts types.HostOptions) (types.Host, error) {
 		return nil, fmt.Errorf("failed to dial %s: %w", opts.Address, err)
 	}
 
+	ap, err := opts.ParseAddress()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse address: %w", err)
+	}
+
 	return &Host{
-		client: c,
+		client:  c,
+		forward: proxies,
+		host:    ap.Host,
 	}, nil
 }
 
@@ -80,8 +101,28 @@ func proxyWith(
 	return proxyWith(append(pds, d), dhs)
 }
 
+// Dial connects to the given address via the proxies,
+// since WinRM cannot forward connections,
+// the loopback address is translated to the host address.
+func (h *Host) Dial(
+	_ context.Context,
+	network, address string,
+) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	if ip := net.ParseIP(host); host == "" || host == "localhost" ||
+		(ip != nil && ip.IsLoopback()) {
+		address = net.JoinHostPort(h.host, port)
+	}
+
+	return h.forward.Dial(network, address)
+}
+
 func (h *Host) Close() error {
-	return nil
+	return h.forward.Close()
 }
 
 func (h *Host) State(ctx context.Context) (types.HostStatus, error) {
@@ -168,6 +209,15 @@ func (h *Host) ExecuteWithOutput(
 	return buf.Bytes(), err
 }
 
+func (h *Host) ExecuteWithStream(
+	ctx context.Context,
+	out io.Writer,
+	cmd string,
+	args ...string,
+) error {
+	return h.execute(ctx, iox.SingleWriter(out), cmd, args)
+}
+
 func (h *Host) execute(
 	ctx context.Context,
 	out io.Writer,
@@ -180,6 +230,14 @@ func (h *Host) execute(
 
 	command := codec.EncodeShellInput("windows", cmd, args, "")
 
-	_, err := h.client.RunWithContext(ctx, command, out, out)
-	return err
+	code, err := h.client.RunWithContext(ctx, command, out, out)
+	if err != nil {
+		return err
+	}
+
+	if code != 0 {
+		return &types.ExitError{Code: code}
+	}
+
+	return nil
 }
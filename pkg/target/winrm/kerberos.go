@@ -0,0 +1,165 @@
+package winrm
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/jcmturner/gokrb5/v8/client"
+	"github.com/jcmturner/gokrb5/v8/config"
+	"github.com/jcmturner/gokrb5/v8/credentials"
+	"github.com/jcmturner/gokrb5/v8/keytab"
+	"github.com/jcmturner/gokrb5/v8/spnego"
+	"github.com/masterzen/winrm"
+	"github.com/masterzen/winrm/soap"
+
+	"github.com/seal-io/terraform-provider-courier/pkg/target/types"
+)
+
+// kerberosTransporter authenticates the WinRM requests via SPNEGO,
+// unlike winrm.ClientKerberos, it dials through the proxies,
+// supports the keytab and logins once for all requests.
+type kerberosTransporter struct {
+	dial     func(network, addr string) (net.Conn, error)
+	user     string
+	password string
+	opts     types.HostOptionKerberos
+
+	url       string
+	spn       string
+	client    *client.Client
+	transport http.RoundTripper
+}
+
+func newKerberosTransporter(
+	dial func(network, addr string) (net.Conn, error),
+	au types.HostOptionAuthn,
+) *kerberosTransporter {
+	return &kerberosTransporter{
+		dial:     dial,
+		user:     au.User,
+		password: au.Secret,
+		opts:     au.Kerberos,
+	}
+}
+
+func (t *kerberosTransporter) Transport(ep *winrm.Endpoint) error {
+	cfgPath := t.opts.Config
+	if cfgPath == "" {
+		cfgPath = os.Getenv("KRB5_CONFIG")
+	}
+
+	if cfgPath == "" {
+		cfgPath = "/etc/krb5.conf"
+	}
+
+	cfg, err := config.Load(cfgPath)
+	if err != nil {
+		return fmt.Errorf("failed to load krb5.conf %s: %w", cfgPath, err)
+	}
+
+	switch {
+	case t.opts.CCache != "":
+		cc, err := credentials.LoadCCache(t.opts.CCache)
+		if err != nil {
+			return fmt.Errorf("failed to load ccache %s: %w", t.opts.CCache, err)
+		}
+
+		t.client, err = client.NewFromCCache(cc, cfg, client.DisablePAFXFAST(true))
+		if err != nil {
+			return fmt.Errorf("failed to create kerberos client from ccache: %w", err)
+		}
+	case t.opts.Keytab != "":
+		kt, err := keytab.Load(t.opts.Keytab)
+		if err != nil {
+			return fmt.Errorf("failed to load keytab %s: %w", t.opts.Keytab, err)
+		}
+
+		t.client = client.NewWithKeytab(t.user, t.opts.Realm, kt, cfg,
+			client.DisablePAFXFAST(true))
+	case t.password != "":
+		t.client = client.NewWithPassword(t.user, t.opts.Realm, t.password, cfg,
+			client.DisablePAFXFAST(true), client.AssumePreAuthentication(true))
+	default:
+		return errors.New("no password, keytab or ccache specified")
+	}
+
+	scheme := "http"
+	if ep.HTTPS {
+		scheme = "https"
+	}
+
+	t.url = fmt.Sprintf("%s://%s/wsman", scheme,
+		net.JoinHostPort(ep.Host, fmt.Sprint(ep.Port)))
+
+	t.spn = t.opts.SPN
+	if t.spn == "" {
+		t.spn = "HTTP/" + ep.Host
+	}
+
+	dial := t.dial
+	if dial == nil {
+		dial = (&net.Dialer{Timeout: ep.Timeout}).Dial
+	}
+
+	tr := &http.Transport{
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: ep.Insecure, //nolint:gosec
+			ServerName:         ep.TLSServerName,
+		},
+		Dial:                  dial,
+		ResponseHeaderTimeout: ep.Timeout,
+	}
+
+	if len(ep.CACert) != 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ep.CACert) {
+			return errors.New("invalid CA certificate")
+		}
+
+		tr.TLSClientConfig.RootCAs = pool
+	}
+
+	t.transport = tr
+
+	return nil
+}
+
+func (t *kerberosTransporter) Post(_ *winrm.Client, request *soap.SoapMessage) (string, error) {
+	req, err := http.NewRequest( //nolint:noctx
+		http.MethodPost, t.url, strings.NewReader(request.String()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/soap+xml;charset=UTF-8")
+
+	err = spnego.SetSPNEGOHeader(t.client, req, t.spn)
+	if err != nil {
+		return "", fmt.Errorf("failed to negotiate with %s: %w", t.spn, err)
+	}
+
+	resp, err := (&http.Client{Transport: t.transport}).Do(req)
+	if err != nil {
+		return "", err
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected response %s: %s", resp.Status, body)
+	}
+
+	return string(body), nil
+}
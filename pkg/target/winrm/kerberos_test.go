@@ -0,0 +1,105 @@
+package winrm
+
+import (
+	"encoding/pem"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/masterzen/winrm"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seal-io/terraform-provider-courier/pkg/target/types"
+)
+
+func TestKerberosTransporter_Transport(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	// Mute the handshake error of the unpinned case.
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+
+	defer srv.Close()
+
+	host, port, err := net.SplitHostPort(srv.Listener.Addr().String())
+	if !assert.NoError(t, err, "should not return error") {
+		return
+	}
+
+	portNum, _ := strconv.Atoi(port)
+
+	cfg := filepath.Join(t.TempDir(), "krb5.conf")
+
+	err = os.WriteFile(cfg, []byte(`
+[libdefaults]
+  default_realm = EXAMPLE.COM
+
+[realms]
+  EXAMPLE.COM = {
+    kdc = 127.0.0.1:88
+  }
+`), 0o600)
+	if !assert.NoError(t, err, "should not return error") {
+		return
+	}
+
+	caCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+
+	testCases := []struct {
+		name    string
+		caCert  []byte
+		wantErr bool
+	}{
+		{
+			name:   "pinned CA",
+			caCert: caCert,
+		},
+		{
+			name:    "unpinned CA",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := newKerberosTransporter(nil, types.HostOptionAuthn{
+				User:   "administrator",
+				Secret: "password",
+				Kerberos: types.HostOptionKerberos{
+					Realm:  "EXAMPLE.COM",
+					Config: cfg,
+				},
+			})
+
+			ep := winrm.NewEndpoint(host, portNum, true, false, tc.caCert, nil, nil, 5*time.Second)
+
+			err := tr.Transport(ep)
+			if !assert.NoError(t, err, "should not return error") {
+				return
+			}
+
+			assert.Equal(t, "https://"+srv.Listener.Addr().String()+"/wsman", tr.url)
+			assert.Equal(t, "HTTP/"+host, tr.spn)
+
+			// Verify the server with the pinned CA only.
+			resp, err := (&http.Client{Transport: tr.transport}).Get(srv.URL) //nolint:noctx
+			if tc.wantErr {
+				assert.Error(t, err, "should return error")
+				return
+			}
+
+			if assert.NoError(t, err, "should not return error") {
+				_ = resp.Body.Close()
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+			}
+		})
+	}
+}
@@ -1,7 +1,6 @@
 package winrm
 
 import (
-	"bufio"
 	"context"
 	"errors"
 	"fmt"
@@ -13,12 +12,13 @@ import (
 	"github.com/seal-io/terraform-provider-courier/pkg/target/codec"
 	"github.com/seal-io/terraform-provider-courier/pkg/target/types"
 	"github.com/seal-io/terraform-provider-courier/utils/bytespool"
+	"github.com/seal-io/terraform-provider-courier/utils/iox"
 	"github.com/seal-io/terraform-provider-courier/utils/strx"
 )
 
 type Terminal struct {
-	io.WriteCloser
-	io.Reader
+	input  io.Writer
+	output *codec.ShellReader
 
 	shell   *winrm.Shell
 	command *winrm.Command
@@ -27,9 +27,10 @@ type Terminal struct {
 
 func (h *Host) Shell(
 	ctx context.Context,
-	cmdArgs ...string,
+	opts ...types.ShellOption,
 ) (types.Terminal, error) {
 	echo := fmt.Sprintf(`#%s#`, strx.Hex(8))
+	o := types.NewShellOptions(opts...)
 
 	s, err := h.client.CreateShell()
 	if err != nil {
@@ -41,9 +42,9 @@ func (h *Host) Shell(
 		args []string
 	)
 
-	if len(cmdArgs) > 0 {
-		cmd = cmdArgs[0]
-		args = cmdArgs[1:]
+	if len(o.Command) > 0 {
+		cmd = o.Command[0]
+		args = o.Command[1:]
 	} else {
 		cmd = "powershell.exe"
 	}
@@ -54,9 +55,13 @@ func (h *Host) Shell(
 		return nil, err
 	}
 
+	// NB(thxCode): WinRM does not allocate pseudo terminal.
+	o.PTY = false
+	input := iox.SingleWriter(c.Stdin)
+
 	return &Terminal{
-		WriteCloser: c.Stdin,
-		Reader:      io.MultiReader(c.Stdout, c.Stderr),
+		input:  input,
+		output: codec.NewShellReader(input, o, c.Stdout, c.Stderr),
 
 		shell:   s,
 		command: c,
@@ -64,9 +69,19 @@ func (h *Host) Shell(
 	}, nil
 }
 
+func (t *Terminal) Read(p []byte) (int, error) {
+	return t.output.Read(p)
+}
+
+func (t *Terminal) Write(p []byte) (int, error) {
+	return t.input.Write(p)
+}
+
 func (t *Terminal) Close() error {
 	defer func() { _ = t.shell.Close() }()
 
+	_ = t.output.Close()
+
 	return t.command.Close()
 }
 
@@ -99,22 +114,30 @@ func (t *Terminal) execute(wr io.Writer, cmd string, args []string) error {
 	})
 
 	g.Go(func() error {
-		s := bufio.NewScanner(t)
-		for s.Scan() {
-			output := s.Text()
+		for i := 0; ; i++ {
+			output, err := t.output.ReadLine()
+			if err != nil {
+				return err
+			}
 
 			found, err := codec.DecodeShellOutput(&output, t.echo)
 			if found {
 				return err
 			}
 
+			// Separate the lines except the last one.
+			if i > 0 {
+				_, err = wr.Write([]byte{'\n'})
+				if err != nil {
+					return err
+				}
+			}
+
 			_, err = wr.Write(strx.ToBytes(&output))
 			if err != nil {
 				return err
 			}
 		}
-
-		return s.Err()
 	})
 
 	return g.Wait()
@@ -3,6 +3,7 @@ package winrm
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"io/fs"
 
@@ -14,6 +15,7 @@ func (h *Host) UploadFile(
 	ctx context.Context,
 	from types.FileReader,
 	to string,
+	opts ...types.TransmitOption,
 ) (err error) {
 	if from == nil {
 		return errors.New("nil local file reader")
@@ -45,10 +47,150 @@ func (h *Host) UploadFile(
 	// https://github.com/packer-community/winrmcp/blob/6e900dd2c68f81845f61265562b6299a806162e0/winrmcp/cp.go.
 	bs := ((8000 - len(to)) / 4) * 3
 
+	p := types.NewTransmitProgressor(types.SizeOf(from), opts...)
+
 	buf := bytespool.GetBytes(bs)
 	defer func() { bytespool.Put(buf) }()
 
-	_, err = io.CopyBuffer(wr, from, buf)
+	_, err = io.CopyBuffer(wr, struct{ io.Reader }{p.Reader(from)}, buf)
+	if err != nil {
+		return err
+	}
+
+	p.Done()
+
+	return nil
+}
+
+func (h *Host) UploadFileResumable(
+	ctx context.Context,
+	from types.FileReadSeeker,
+	to string,
+	opts ...types.TransmitOption,
+) (err error) {
+	if from == nil {
+		return errors.New("nil local file reader")
+	}
+
+	if to == "" {
+		return errors.New("blank remote file path")
+	}
+
+	total, err := from.Seek(0, io.SeekEnd)
+	if err != nil {
+		return fmt.Errorf("failed to seek local file: %w", err)
+	}
+
+	sum, err := types.SumSHA256(from, -1)
+	if err != nil {
+		return err
+	}
+
+	c, err := h.getFileTransportWithContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = c.Close() }()
+
+	partial := to + types.PartialSuffix
+
+	p := types.NewTransmitProgressor(total, opts...)
+
+	// Retry from the partial file left by the failed attempt.
+	for i := 1; ; i++ {
+		offset, err := resumeOffset(c, from, partial, total)
+		if err != nil {
+			return err
+		}
+
+		p.Resume(offset)
+
+		if offset == total {
+			break
+		}
+
+		err = appendFrom(c, from, partial, offset, p)
+		if err == nil {
+			break
+		}
+
+		if i >= types.ResumableAttempts || ctx.Err() != nil {
+			return err
+		}
+	}
+
+	p.Done()
+
+	// Verify.
+	rs, err := c.SumSHA256(partial)
+	if err != nil {
+		return err
+	}
+
+	if rs != sum {
+		_ = c.Remove(partial)
+		return fmt.Errorf("mismatched remote checksum sha256:%s, expected sha256:%s", rs, sum)
+	}
+
+	return c.Rename(partial, to)
+}
+
+// resumeOffset returns the size of the given partial file if it is the prefix of the local file,
+// otherwise, removes the partial file and returns zero to upload from scratch.
+func resumeOffset(
+	c *fileTransport,
+	from types.FileReadSeeker,
+	partial string,
+	total int64,
+) (int64, error) {
+	if i, err := c.Lstat(partial); err == nil && !i.IsDir() && i.Size() <= total {
+		ls, err := types.SumSHA256(from, i.Size())
+		if err != nil {
+			return 0, err
+		}
+
+		if rs, err := c.SumSHA256(partial); err == nil && rs == ls {
+			return i.Size(), nil
+		}
+	}
+
+	return 0, c.Remove(partial)
+}
+
+// appendFrom appends the given file from the given offset to the given remote path.
+func appendFrom(
+	c *fileTransport,
+	from types.FileReadSeeker,
+	to string,
+	offset int64,
+	p *types.TransmitProgressor,
+) (err error) {
+	if _, err = from.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek local file: %w", err)
+	}
+
+	wr, err := c.Append(to)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if cerr := wr.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	// NB(thxCode): Inspired by
+	// https://github.com/packer-community/winrmcp/blob/6e900dd2c68f81845f61265562b6299a806162e0/winrmcp/cp.go.
+	bs := ((8000 - len(to)) / 4) * 3
+
+	buf := bytespool.GetBytes(bs)
+	defer func() { bytespool.Put(buf) }()
+
+	// NB(thxCode): Hide the io.WriterTo of the local file to respect the chunk size.
+	_, err = io.CopyBuffer(wr, struct{ io.Reader }{p.Reader(from)}, buf)
+
 	return err
 }
 
@@ -56,6 +198,7 @@ func (h *Host) UploadDirectory(
 	ctx context.Context,
 	from types.DirectoryReader,
 	to string,
+	opts ...types.TransmitOption,
 ) error {
 	if from == nil {
 		return errors.New("nil local directory reader")
@@ -77,7 +220,9 @@ func (h *Host) UploadDirectory(
 		return err
 	}
 
-	return fs.WalkDir(
+	tp := types.NewTransmitProgressor(types.SizeOfDirectory(from), opts...)
+
+	err = fs.WalkDir(
 		from,
 		".",
 		func(p string, d fs.DirEntry, ierr error) (err error) {
@@ -114,10 +259,17 @@ func (h *Host) UploadDirectory(
 			buf := bytespool.GetBytes(bs)
 			defer func() { bytespool.Put(buf) }()
 
-			_, err = io.CopyBuffer(wr, rd, buf)
+			_, err = io.CopyBuffer(wr, struct{ io.Reader }{tp.Reader(rd)}, buf)
 			return err
 		},
 	)
+	if err != nil {
+		return err
+	}
+
+	tp.Done()
+
+	return nil
 }
 
 func (h *Host) DownloadFile(
@@ -22,3 +22,49 @@ func (s *singleWriter) Write(p []byte) (n int, err error) {
 	defer s.m.Unlock()
 	return s.Writer.Write(p)
 }
+
+// LineWriter returns a writer which calls the given function with each line written,
+// lines are separated by line feed or carriage return, blank lines are skipped,
+// the rest without separator is passed on Close.
+func LineWriter(fn func(line string)) io.WriteCloser {
+	return &lineWriter{
+		fn: fn,
+	}
+}
+
+type lineWriter struct {
+	fn  func(line string)
+	buf []byte
+}
+
+// maxLineSize is the maximum size of a line,
+// a longer line is split.
+const maxLineSize = 64 << 10
+
+func (l *lineWriter) Write(p []byte) (int, error) {
+	for _, b := range p {
+		if b != '\n' && b != '\r' {
+			l.buf = append(l.buf, b)
+			if len(l.buf) < maxLineSize {
+				continue
+			}
+		}
+
+		l.flush()
+	}
+
+	return len(p), nil
+}
+
+func (l *lineWriter) Close() error {
+	l.flush()
+	return nil
+}
+
+func (l *lineWriter) flush() {
+	if len(l.buf) != 0 {
+		l.fn(string(l.buf))
+	}
+
+	l.buf = l.buf[:0]
+}